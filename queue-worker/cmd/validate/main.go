@@ -0,0 +1,40 @@
+// Command validate checks a weather message against the worker's validation
+// rules, reading the JSON body from the file named by the first argument or
+// from stdin. It uses the same configuration environment as the worker.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"queue-worker/internal/config"
+	"queue-worker/internal/validator"
+)
+
+func main() {
+	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		os.Exit(2)
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if len(os.Args) > 1 && os.Args[1] != "-" {
+		data, err = os.ReadFile(os.Args[1])
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read message:", err)
+		os.Exit(2)
+	}
+
+	v := validator.New(cfg.ValidatorOptions())
+	if !v.Report(os.Stdout, data) {
+		os.Exit(1)
+	}
+}
@@ -1,35 +1,158 @@
 package main
 
 import (
+	"context"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata" // LOG_TIMEZONE names resolve on images without tzdata
 
 	"queue-worker/internal/api_client"
 	"queue-worker/internal/config"
 	"queue-worker/internal/consumer"
+	"queue-worker/internal/enrich"
 	"queue-worker/internal/logger"
+	"queue-worker/internal/schema"
+	"queue-worker/internal/spool"
 )
 
 func main() {
-	log := logger.New("queue-worker")
+	cfg := config.Load()
 
-	log.Info("Starting queue worker", nil)
+	level, _ := logger.ParseLevel(cfg.LogLevel) // checked by Validate
+	timeLayout, _ := logger.ParseTimestampFormat(cfg.LogTimestampFormat)
+	timeZone, _ := time.LoadLocation(cfg.LogTimezone)
+	logOpts := []logger.Option{
+		logger.WithLevel(level),
+		logger.WithTimestampFormat(timeLayout),
+		logger.WithTimezone(timeZone),
+		logger.WithDedup(cfg.LogDedupWindow),
+		logger.WithDebugSampling(cfg.LogDebugSampleFirst, cfg.LogDebugSampleThereafter, cfg.LogDebugSampleWindow),
+		logger.WithMaxEntries(cfg.LogBufferEntries),
+	}
+	if cfg.LogSplitStreams {
+		logOpts = append(logOpts, logger.WithSplitStreams(nil, nil))
+	}
+	log := logger.New("queue-worker", logOpts...)
+	defer log.Flush()
 
-	cfg := config.Load()
+	log.Info("Starting queue worker", nil)
 
+	redacted := cfg.Redacted()
 	log.Info("Configuration loaded", map[string]interface{}{
-		"rabbitmq_url":   cfg.RabbitMQURL,
+		"rabbitmq_url":   redacted.RabbitMQURL,
 		"queue_name":     cfg.QueueName,
-		"api_url":        cfg.APIServiceURL,
+		"api_url":        redacted.APIServiceURL,
+		"api_fallbacks":  redacted.APIFallbackURLs,
+		"api_sources":    redacted.APISourceURLs,
+		"api_proxy":      redacted.APIProxyURL,
 		"retry_attempts": cfg.RetryAttempts,
+		"heartbeat":      cfg.Heartbeat.String(),
 	})
 
-	apiClient := api_client.NewClient(cfg.APIServiceURL)
+	if err := cfg.Validate(); err != nil {
+		log.Error("Invalid configuration", map[string]interface{}{
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+
+	encoder, ok := api_client.EncoderByName(cfg.APIEncoding)
+	if !ok {
+		log.Error("Unsupported API encoding", map[string]interface{}{
+			"encoding": cfg.APIEncoding,
+		})
+		os.Exit(1)
+	}
+
+	regions, ok := enrich.LookupByName(cfg.RegionLookup)
+	if !ok {
+		log.Error("Unsupported region lookup", map[string]interface{}{
+			"region_lookup": cfg.RegionLookup,
+		})
+		os.Exit(1)
+	}
+
+	var tokens api_client.TokenSource = api_client.StaticToken(cfg.APIAuthToken)
+	if cfg.APIAuthTokenFile != "" {
+		tokenFile, err := api_client.NewTokenFile(cfg.APIAuthTokenFile, cfg.APIAuthTokenReload)
+		if err != nil {
+			log.Error("Failed to load API auth token", map[string]interface{}{
+				"error": err.Error(),
+				"path":  cfg.APIAuthTokenFile,
+			})
+			os.Exit(1)
+		}
+		tokens = tokenFile
+	}
+
+	proxyURL, _ := cfg.ProxyURL() // checked by Validate
+	clientOpts := []api_client.Option{
+		api_client.WithEncoder(encoder),
+		api_client.WithMethod(cfg.APIHTTPMethod),
+		api_client.WithFallbackURLs(cfg.APIFallbackURLs...),
+		api_client.WithSourceURLs(cfg.APISourceURLs),
+		api_client.WithAlertsURL(cfg.APIAlertsURL),
+		api_client.WithPrimaryProbeInterval(cfg.APIPrimaryProbeInterval),
+		api_client.WithTokenSource(tokens),
+		api_client.WithMaxInFlight(cfg.MaxInFlightRequests),
+		api_client.WithBodyReadTimeout(cfg.APIBodyReadTimeout),
+		api_client.WithMaxBatchBytes(cfg.APIMaxBatchBytes),
+		api_client.WithProxyURL(proxyURL),
+	}
+	clientOpts = append(clientOpts, tlsOptions(cfg, log)...)
+	if cfg.APIStubStatus != 0 {
+		log.Warn("API stub enabled, messages will not be delivered", map[string]interface{}{
+			"status_code": cfg.APIStubStatus,
+			"latency_ms":  cfg.APIStubLatency.Milliseconds(),
+		})
+		clientOpts = append(clientOpts, api_client.WithStub(cfg.APIStubStatus, cfg.APIStubLatency))
+	}
+	apiClient := api_client.NewClient(cfg.APIServiceURL, clientOpts...)
 
 	cons := consumer.New(cfg, apiClient, log)
+	cons.SetRegionLookup(regions)
+	cons.SetTransformer(cfg.MessageTransformer())
 
-	if err := cons.Connect(); err != nil {
+	if cfg.SchemaRegistryURL != "" {
+		schemas := schema.NewCachedProvider(schema.NewHTTPProvider(cfg.SchemaRegistryURL, nil), cfg.SchemaCacheTTL)
+		schemas.OnError = func(source string, version int, err error) {
+			log.Warn("Failed to fetch message schema", map[string]interface{}{
+				"source":    source,
+				"version":   version,
+				"error":     err.Error(),
+				"fail_open": cfg.SchemaFailOpen,
+			})
+		}
+		cons.SetSchemaProvider(schemas)
+	}
+
+	if cfg.SpoolPath != "" {
+		sp, err := spool.Open(cfg.SpoolPath, cfg.SpoolMaxBytes)
+		if err != nil {
+			log.Error("Failed to open spool", map[string]interface{}{
+				"error": err.Error(),
+				"path":  cfg.SpoolPath,
+			})
+			os.Exit(1)
+		}
+		cons.SetSpool(sp)
+	}
+
+	if cfg.MetricsAddr != "" {
+		if err := startMetricsServer(cfg.MetricsAddr, cons, log, cfg.DebugLogEndpoint); err != nil {
+			log.Error("Failed to start metrics server", map[string]interface{}{
+				"error": err.Error(),
+				"addr":  cfg.MetricsAddr,
+			})
+			os.Exit(1)
+		}
+	}
+
+	if err := cons.ConnectWithRetry(); err != nil {
 		log.Error("Failed to connect to RabbitMQ", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -37,21 +160,134 @@ func main() {
 	}
 	defer cons.Close()
 
-	// Handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Handle graceful shutdown: the signal cancels ctx, which makes Start stop
+	// consuming and return once in-flight messages are done
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
 	go func() {
-		<-sigChan
+		for range reload {
+			reloadConfig(cfg, cons, log)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
 		log.Info("Received shutdown signal", nil)
-		cons.Close()
-		os.Exit(0)
+
+		deadline, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDeadline)
+		defer cancel()
+		if stuck, timedOut := cons.Shutdown(deadline); timedOut {
+			log.Warn("Shutdown deadline exceeded, forcing exit", map[string]interface{}{
+				"stuck_messages": stuck,
+				"deadline":       cfg.ShutdownDeadline.String(),
+			})
+			cons.LogShutdownReport(stuck)
+			log.Flush()
+			os.Exit(1)
+		}
 	}()
 
-	if err := cons.Start(); err != nil {
+	if err := cons.Start(ctx); err != nil {
 		log.Error("Consumer error", map[string]interface{}{
 			"error": err.Error(),
 		})
+		log.Flush()
 		os.Exit(1)
 	}
+	cons.LogShutdownReport(0)
+}
+
+// reloadConfig re-reads the settings that can change while running (retry
+// attempts, retry delay and log level), from the CONFIG_FILE env file when
+// set, and applies them, logging what changed. Other changed settings are
+// reported as needing a restart and left as they are. An invalid
+// configuration is rejected as a whole.
+func reloadConfig(cfg *config.Config, cons *consumer.Consumer, log *logger.Logger) {
+	next := config.Load()
+	if err := next.Validate(); err != nil {
+		log.Error("Configuration reload rejected", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	changed := map[string]interface{}{}
+	attempts, delay := cons.RetrySettings()
+	if next.RetryAttempts != attempts {
+		changed["retry_attempts"] = next.RetryAttempts
+	}
+	if next.RetryDelay != delay {
+		changed["retry_delay"] = next.RetryDelay.String()
+	}
+	level, _ := logger.ParseLevel(next.LogLevel)
+	if level != log.MinLevel() {
+		changed["log_level"] = string(level)
+	}
+
+	cons.SetRetrySettings(next.RetryAttempts, next.RetryDelay)
+	log.SetLevel(level)
+	log.Info("Configuration reloaded", map[string]interface{}{
+		"changed": changed,
+	})
+
+	if restart := cfg.RestartRequired(next); len(restart) > 0 {
+		log.Warn("Configuration changes need a restart to take effect", map[string]interface{}{
+			"settings": restart,
+		})
+	}
+}
+
+// tlsOptions returns the API client options for cfg's TLS settings, warning
+// when certificate verification is disabled
+func tlsOptions(cfg *config.Config, log *logger.Logger) []api_client.Option {
+	if !cfg.APITLSInsecureSkipVerify {
+		return nil
+	}
+	log.Warn("TLS certificate verification disabled for API requests, do not use in production", map[string]interface{}{
+		"setting": "API_TLS_INSECURE_SKIP_VERIFY",
+		"api_url": cfg.Redacted().APIServiceURL,
+	})
+	return []api_client.Option{api_client.WithInsecureSkipVerify()}
+}
+
+// startMetricsServer binds addr before returning, so a bad address or a port
+// in use fails startup instead of leaving the worker without metrics.
+// debugLogs also serves the recent log entries at /debug/logs and the
+// consumer's stats at /stats.
+func startMetricsServer(addr string, cons *consumer.Consumer, log *logger.Logger, debugLogs bool) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", cons.Registry())
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, _ *http.Request) {
+		if err := cons.CheckReady(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		}
+	})
+	if debugLogs {
+		log.Warn("Debug endpoints enabled, recent log entries and stats are served unauthenticated", map[string]interface{}{
+			"paths": []string{"/debug/logs", "/stats"},
+		})
+		mux.Handle("/debug/logs", log.EntriesHandler())
+		mux.Handle("/stats", cons.StatsHandler())
+	}
+
+	log.Info("Metrics server listening", map[string]interface{}{
+		"addr": listener.Addr().String(),
+	})
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Error("Metrics server stopped", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
+	return nil
 }
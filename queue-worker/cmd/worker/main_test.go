@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/config"
+	"queue-worker/internal/consumer"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for startup wiring
+
+func TestTLSOptions_WarnsWhenVerificationSkipped(t *testing.T) {
+	log := logger.New("test")
+	cfg := &config.Config{APIServiceURL: "https://api.internal", APITLSInsecureSkipVerify: true}
+
+	if opts := tlsOptions(cfg, log); len(opts) != 1 {
+		t.Errorf("Expected the insecure skip verify option, got %d options", len(opts))
+	}
+	if !log.HasLogWithLevel(logger.WARN) {
+		t.Error("Expected a warning when TLS verification is skipped")
+	}
+}
+
+func TestTLSOptions_VerifiesByDefault(t *testing.T) {
+	log := logger.New("test")
+
+	if opts := tlsOptions(config.Load(), log); len(opts) != 0 {
+		t.Errorf("Expected no TLS options by default, got %d", len(opts))
+	}
+	if log.HasLogWithLevel(logger.WARN) {
+		t.Error("Expected no warning by default")
+	}
+}
+
+func TestReloadConfig_AppliesLogLevelAndRetrySettings(t *testing.T) {
+	cfg := config.Load()
+	log := logger.New("test")
+	cons := consumer.New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("RETRY_ATTEMPTS", "5")
+	t.Setenv("RETRY_DELAY_MS", "250")
+	reloadConfig(cfg, cons, log)
+
+	if log.MinLevel() != logger.WARN {
+		t.Errorf("Expected WARN, got %s", log.MinLevel())
+	}
+	if attempts, delay := cons.RetrySettings(); attempts != 5 || delay != 250*time.Millisecond {
+		t.Errorf("Expected 5 attempts 250ms apart, got %d and %v", attempts, delay)
+	}
+	if cfg.RetryAttempts != 5 {
+		t.Errorf("Expected the running config to be updated, got %d", cfg.RetryAttempts)
+	}
+}
+
+func TestReloadConfig_RereadsConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.env")
+	if err := os.WriteFile(path, []byte("LOG_LEVEL=debug\nRETRY_ATTEMPTS=3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	cfg := config.Load()
+	log := logger.New("test", logger.WithLevel(logger.DEBUG))
+	cons := consumer.New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+
+	if err := os.WriteFile(path, []byte("LOG_LEVEL=error\nRETRY_ATTEMPTS=6\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reloadConfig(cfg, cons, log)
+
+	if log.MinLevel() != logger.ERROR {
+		t.Errorf("Expected ERROR from the rewritten file, got %s", log.MinLevel())
+	}
+	if attempts, _ := cons.RetrySettings(); attempts != 6 {
+		t.Errorf("Expected 6 attempts from the rewritten file, got %d", attempts)
+	}
+}
+
+func TestReloadConfig_LeavesConnectionSettings(t *testing.T) {
+	cfg := config.Load()
+	log := logger.New("test")
+	cons := consumer.New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+
+	t.Setenv("RABBITMQ_QUEUE", "other-queue")
+	reloadConfig(cfg, cons, log)
+
+	if cfg.QueueName == "other-queue" {
+		t.Error("Expected the queue name to be left unchanged")
+	}
+	entries := log.GetEntries()
+	last := entries[len(entries)-1]
+	settings, _ := last.Context["settings"].([]string)
+	if last.Message != "Configuration changes need a restart to take effect" || len(settings) != 1 || settings[0] != "RABBITMQ_QUEUE" {
+		t.Errorf("Expected a restart warning for RABBITMQ_QUEUE, got %+v", last)
+	}
+}
+
+func TestReloadConfig_RejectsInvalidLevel(t *testing.T) {
+	cfg := config.Load()
+	log := logger.New("test")
+	cons := consumer.New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("RETRY_ATTEMPTS", "9")
+	reloadConfig(cfg, cons, log)
+
+	if attempts, _ := cons.RetrySettings(); attempts != cfg.RetryAttempts || attempts == 9 {
+		t.Errorf("Expected retry attempts unchanged, got %d", attempts)
+	}
+	if !log.HasLogWithMessage("Configuration reload rejected") {
+		t.Error("Expected the reload to be rejected")
+	}
+}
+
+func TestStartMetricsServer_ServesMetrics(t *testing.T) {
+	// Find a free port, then let the server take it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cfg := config.Load()
+	log := logger.New("test")
+	cons := consumer.New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+	if err := startMetricsServer(addr, cons, log, false); err != nil {
+		t.Fatalf("Expected the server to start, got %v", err)
+	}
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("Expected /metrics to be served, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 from /metrics, got %d", resp.StatusCode)
+	}
+}
+
+func TestStartMetricsServer_FailsWhenAddressInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	cfg := config.Load()
+	log := logger.New("test")
+	cons := consumer.New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+	if err := startMetricsServer(listener.Addr().String(), cons, log, false); err == nil {
+		t.Error("Expected a listen error for an address already in use")
+	}
+	if log.HasLogWithMessage("Metrics server listening") {
+		t.Error("Expected no listening log when the listen fails")
+	}
+}
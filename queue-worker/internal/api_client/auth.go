@@ -0,0 +1,93 @@
+package api_client
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TokenSource supplies the bearer token sent with each API request. An empty
+// token sends no Authorization header.
+type TokenSource interface {
+	Token() string
+}
+
+// StaticToken is a TokenSource that always returns the same token
+type StaticToken string
+
+// Token returns the static token
+func (t StaticToken) Token() string {
+	return string(t)
+}
+
+// WithTokenSource authenticates requests with a bearer token from src
+func WithTokenSource(src TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = src
+	}
+}
+
+// TokenFile is a TokenSource that reads the token from a file, picking up
+// rotated tokens without a restart. The file is stat'ed at most once per
+// interval and re-read when its size or modification time changes.
+type TokenFile struct {
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+// NewTokenFile reads the initial token from path, failing if it can't be read
+func NewTokenFile(path string, interval time.Duration) (*TokenFile, error) {
+	tf := &TokenFile{path: path, interval: interval, now: time.Now}
+	if err := tf.reload(); err != nil {
+		return nil, err
+	}
+	return tf, nil
+}
+
+// Token returns the current token, reloading the file if it changed. If a
+// reload fails, for example mid-rotation, the previous token is kept.
+func (tf *TokenFile) Token() string {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if tf.now().Sub(tf.checkedAt) >= tf.interval {
+		tf.reloadLocked()
+	}
+	return tf.token
+}
+
+func (tf *TokenFile) reload() error {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	return tf.reloadLocked()
+}
+
+func (tf *TokenFile) reloadLocked() error {
+	tf.checkedAt = tf.now()
+
+	info, err := os.Stat(tf.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat token file: %w", err)
+	}
+	if info.ModTime().Equal(tf.modTime) && info.Size() == tf.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(tf.path)
+	if err != nil {
+		return fmt.Errorf("failed to read token file: %w", err)
+	}
+	tf.token = string(bytes.TrimSpace(data))
+	tf.modTime = info.ModTime()
+	tf.size = info.Size()
+	return nil
+}
@@ -0,0 +1,110 @@
+package api_client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Unit tests for API authentication token sources
+
+func TestWithTokenSource_StaticToken(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithTokenSource(StaticToken("secret")))
+	client.SendWeatherData(createTestMessage())
+
+	if auth != "Bearer secret" {
+		t.Errorf("Expected bearer token, got %q", auth)
+	}
+}
+
+func TestWithTokenSource_EmptyTokenSendsNoHeader(t *testing.T) {
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Values("Authorization")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithTokenSource(StaticToken("")))
+	client.SendWeatherData(createTestMessage())
+
+	if len(auth) != 0 {
+		t.Errorf("Expected no Authorization header, got %v", auth)
+	}
+}
+
+func TestTokenFile_ReloadsRotatedToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte("first\n"), 0o600)
+
+	tf, err := NewTokenFile(path, time.Minute)
+	if err != nil {
+		t.Fatalf("Expected token file to load, got: %v", err)
+	}
+	now := time.Now()
+	tf.now = func() time.Time { return now }
+
+	if got := tf.Token(); got != "first" {
+		t.Errorf("Expected trimmed token first, got %q", got)
+	}
+
+	os.WriteFile(path, []byte("second-token\n"), 0o600)
+	if got := tf.Token(); got != "first" {
+		t.Errorf("Expected token to be cached until the reload interval, got %q", got)
+	}
+
+	now = now.Add(time.Minute)
+	if got := tf.Token(); got != "second-token" {
+		t.Errorf("Expected rotated token, got %q", got)
+	}
+}
+
+func TestTokenFile_KeepsTokenWhenFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte("first"), 0o600)
+
+	tf, _ := NewTokenFile(path, 0)
+	os.Remove(path)
+
+	if got := tf.Token(); got != "first" {
+		t.Errorf("Expected last good token while the file is missing, got %q", got)
+	}
+}
+
+func TestNewTokenFile_MissingFile(t *testing.T) {
+	if _, err := NewTokenFile(filepath.Join(t.TempDir(), "missing"), time.Minute); err == nil {
+		t.Error("Expected error for missing token file")
+	}
+}
+
+func TestTokenFile_ConcurrentReads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte("token"), 0o600)
+	tf, _ := NewTokenFile(path, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got := tf.Token(); got != "token" {
+					t.Errorf("Expected token, got %q", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,90 @@
+package api_client
+
+import (
+	"errors"
+
+	"queue-worker/internal/validator"
+)
+
+// ErrBatchTooLarge is returned instead of posting a batch whose encoded body
+// exceeds the client's maximum batch size
+var ErrBatchTooLarge = errors.New("batch exceeds the maximum body size")
+
+// WithMaxBatchBytes caps the encoded body of a batch of several messages.
+// SendWeatherBatch splits larger batches to fit, with a Batcher. Single
+// messages are not capped. Zero or less means no limit.
+func WithMaxBatchBytes(n int) Option {
+	return func(c *Client) {
+		c.maxBatchBytes = n
+	}
+}
+
+// SetBatchSizeObserver calls observe with the encoded size of every batch
+// body the client posts. The consumer sets it to record the sizes in its
+// metrics, which is why it isn't an Option: it is created after the client.
+func (c *Client) SetBatchSizeObserver(observe func(bytes int)) {
+	c.observeBatchSize = observe
+}
+
+// Batcher groups messages into batches whose encoded body stays within the
+// client's maximum batch size. It is not safe for concurrent use.
+type Batcher struct {
+	encoder  Encoder
+	maxBytes int
+	pending  []*validator.WeatherMessage
+	size     int // pending messages' sizes encoded one at a time
+}
+
+// NewBatcher returns a Batcher using the client's encoder and maximum batch
+// size
+func (c *Client) NewBatcher() *Batcher {
+	return &Batcher{encoder: c.encoder, maxBytes: c.maxBatchBytes}
+}
+
+// Add appends msg to the pending batch. When msg would take the batch over
+// the limit, the pending messages are returned as a full batch to send and
+// msg starts the next one. A message that doesn't fit even on its own is not
+// added and ErrBatchTooLarge is returned.
+func (b *Batcher) Add(msg *validator.WeatherMessage) ([]*validator.WeatherMessage, error) {
+	if b.maxBytes <= 0 {
+		b.pending = append(b.pending, msg)
+		return nil, nil
+	}
+
+	data, err := b.encoder.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	size := len(data)
+	if b.encodedSize(len(b.pending)+1, b.size+size) <= b.maxBytes {
+		b.pending = append(b.pending, msg)
+		b.size += size
+		return nil, nil
+	}
+	if b.encodedSize(1, size) > b.maxBytes {
+		return nil, ErrBatchTooLarge
+	}
+
+	full := b.Flush()
+	b.pending = append(b.pending, msg)
+	b.size = size
+	return full, nil
+}
+
+// Flush returns the pending messages, nil if there are none, and starts a
+// new batch
+func (b *Batcher) Flush() []*validator.WeatherMessage {
+	full := b.pending
+	b.pending = nil
+	b.size = 0
+	return full
+}
+
+// encodedSize returns the body size of n messages whose sizes encoded one at
+// a time add up to size
+func (b *Batcher) encodedSize(n, size int) int {
+	if b.encoder.Overhead == nil {
+		return size
+	}
+	return size + b.encoder.Overhead(n)
+}
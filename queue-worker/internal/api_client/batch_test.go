@@ -0,0 +1,137 @@
+package api_client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"queue-worker/internal/validator"
+)
+
+// Unit tests for batch size limits
+
+func TestBatcher_NoBatchExceedsMaxBytes(t *testing.T) {
+	for _, encoder := range []Encoder{JSONEncoder, NDJSONEncoder} {
+		const maxBytes = 2048
+		client := NewClient("http://example.com", WithEncoder(encoder), WithMaxBatchBytes(maxBytes))
+		batcher := client.NewBatcher()
+
+		var batches [][]*validator.WeatherMessage
+		for i := 0; i < 200; i++ {
+			msg := createTestMessage()
+			msg.Location.City = strings.Repeat("x", i%40) + fmt.Sprint(i)
+			full, err := batcher.Add(msg)
+			if err != nil {
+				t.Fatalf("Unexpected error adding message %d: %v", i, err)
+			}
+			if full != nil {
+				batches = append(batches, full)
+			}
+		}
+		if last := batcher.Flush(); last != nil {
+			batches = append(batches, last)
+		}
+
+		sent := 0
+		for i, batch := range batches {
+			data, _ := encoder.Marshal(batch...)
+			if len(data) > maxBytes {
+				t.Errorf("%s: batch of %d messages is %d bytes, over the %d cap", encoder.ContentType, len(batch), len(data), maxBytes)
+			}
+			if i < len(batches)-1 {
+				// A full batch flushed only because the next message didn't fit
+				next, _ := encoder.Marshal(append(batch, batches[i+1][0])...)
+				if len(next) <= maxBytes {
+					t.Errorf("%s: batch %d flushed early at %d bytes", encoder.ContentType, i, len(data))
+				}
+			}
+			sent += len(batch)
+		}
+		if sent != 200 || len(batches) < 2 {
+			t.Errorf("%s: expected 200 messages over several batches, got %d in %d", encoder.ContentType, sent, len(batches))
+		}
+	}
+}
+
+func TestBatcher_RejectsMessageLargerThanCap(t *testing.T) {
+	client := NewClient("http://example.com", WithMaxBatchBytes(64))
+	batcher := client.NewBatcher()
+
+	if _, err := batcher.Add(createTestMessage()); !errors.Is(err, ErrBatchTooLarge) {
+		t.Errorf("Expected ErrBatchTooLarge, got %v", err)
+	}
+	if pending := batcher.Flush(); pending != nil {
+		t.Errorf("Expected oversized message not to be added, got %v", pending)
+	}
+}
+
+func TestSendWeatherBatch_SplitsBatchToFit(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sizes = append(sizes, len(body))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	one, _ := JSONEncoder.Marshal(createTestMessage())
+	maxBytes := 2*len(one) + 3 // two messages in an array
+	var observed []int
+	client := NewClient(server.URL, WithMaxBatchBytes(maxBytes))
+	client.SetBatchSizeObserver(func(bytes int) { observed = append(observed, bytes) })
+
+	msgs := []*validator.WeatherMessage{createTestMessage(), createTestMessage(), createTestMessage(), createTestMessage()}
+	if resp := client.SendWeatherBatch(msgs, nil); !resp.IsSuccess() {
+		t.Fatalf("Expected the split batch to be sent, got %+v", resp)
+	}
+	if len(sizes) != 2 || sizes[0] != maxBytes || sizes[1] != maxBytes {
+		t.Errorf("Expected two requests of %d bytes, got %v", maxBytes, sizes)
+	}
+	if len(observed) != 2 || observed[0] != maxBytes {
+		t.Errorf("Expected each batch size observed, got %v", observed)
+	}
+}
+
+func TestSendWeatherBatch_RejectsMessageLargerThanCap(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithMaxBatchBytes(64))
+	msgs := []*validator.WeatherMessage{createTestMessage(), createTestMessage()}
+	if resp := client.SendWeatherBatch(msgs, nil); !errors.Is(resp.Error, ErrBatchTooLarge) {
+		t.Errorf("Expected ErrBatchTooLarge, got %+v", resp)
+	}
+	if calls != 0 {
+		t.Errorf("Expected nothing posted, got %d calls", calls)
+	}
+
+	if resp := client.SendWeatherBatch(msgs[:1], nil); !errors.Is(resp.Error, ErrBatchTooLarge) {
+		t.Errorf("Expected ErrBatchTooLarge for a lone oversized message, got %+v", resp)
+	}
+}
+
+func TestSendWeatherBatch_ObservesUncappedBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var observed []int
+	client := NewClient(server.URL)
+	client.SetBatchSizeObserver(func(bytes int) { observed = append(observed, bytes) })
+
+	msgs := []*validator.WeatherMessage{createTestMessage(), createTestMessage(), createTestMessage()}
+	client.SendWeatherBatch(msgs, nil)
+	data, _ := JSONEncoder.Marshal(msgs...)
+	if len(observed) != 1 || observed[0] != len(data) {
+		t.Errorf("Expected the batch size observed once as %d bytes, got %v", len(data), observed)
+	}
+}
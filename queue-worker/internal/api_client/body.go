@@ -0,0 +1,74 @@
+package api_client
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultBodyReadTimeout is how long a response body read may stall before
+// the request fails, unless WithBodyReadTimeout says otherwise
+const DefaultBodyReadTimeout = 5 * time.Second
+
+// errBodyReadTimeout reports a response body that stopped sending data
+var errBodyReadTimeout = errors.New("response body read timed out")
+
+// WithBodyReadTimeout fails a request when reading its response body makes no
+// progress for d, so a server that sends headers and then trickles or stalls
+// the body can't hold the request for the whole client timeout. The timer
+// restarts on every read that returns data. Zero or less disables it.
+func WithBodyReadTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.bodyReadTimeout = d
+	}
+}
+
+// readBody reads body to the end, closing it to unblock a pending read when
+// no data arrives within timeout
+func readBody(body io.ReadCloser, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		return io.ReadAll(body)
+	}
+
+	r := &stallReader{body: body, timeout: timeout}
+	r.timer = time.AfterFunc(timeout, r.stall)
+	defer r.timer.Stop()
+
+	data, err := io.ReadAll(r)
+	if r.stalled() {
+		return data, errBodyReadTimeout
+	}
+	return data, err
+}
+
+// stallReader restarts its timer whenever a read makes progress
+type stallReader struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+func (r *stallReader) stall() {
+	r.mu.Lock()
+	r.timedOut = true
+	r.mu.Unlock()
+	r.body.Close()
+}
+
+func (r *stallReader) stalled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.timedOut
+}
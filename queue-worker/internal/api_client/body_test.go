@@ -0,0 +1,72 @@
+package api_client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Unit tests for the response body read timeout
+
+// stallingServer sends headers and the start of a body, then stalls until the
+// test ends
+func stallingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server
+}
+
+func TestSendWeatherData_StalledBodyTimesOut(t *testing.T) {
+	server := stallingServer(t)
+	client := NewClient(server.URL, WithBodyReadTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	resp := client.SendWeatherData(createTestMessage())
+
+	if !errors.Is(resp.Error, errBodyReadTimeout) {
+		t.Errorf("Expected body read timeout, got %v", resp.Error)
+	}
+	if resp.IsSuccess() {
+		t.Error("Expected a stalled body not to count as success")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the stalled read to fail quickly, took %v", elapsed)
+	}
+}
+
+func TestSendWeatherData_SlowBodyWithinTimeoutSucceeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		for _, chunk := range []string{`{"id"`, `: "`, `test-id`, `"}`} {
+			w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithBodyReadTimeout(200*time.Millisecond))
+	resp := client.SendWeatherData(createTestMessage())
+
+	if resp.Error != nil {
+		t.Errorf("Expected no error while the body keeps arriving, got %v", resp.Error)
+	}
+	if id, _ := resp.ResourceID(); id != "test-id" {
+		t.Errorf("Expected the full body to be read, got %q", resp.Body)
+	}
+}
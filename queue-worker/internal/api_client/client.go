@@ -2,59 +2,309 @@ package api_client
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"queue-worker/internal/validator"
 )
 
-// Client handles HTTP communication with the API Service
+// Client handles HTTP communication with the API Service. With fallback URLs
+// configured, requests fail over to the next endpoint on connection errors or
+// 5xx responses, and the client keeps using the endpoint that last worked.
 type Client struct {
-	baseURL    string
+	endpoints  []string          // primary first, then fallbacks
+	sourceURLs map[string]string // per-source endpoints, see WithSourceURLs
+	alertsURL  string            // endpoint for alerts, see WithAlertsURL
 	httpClient *http.Client
+	method     string // HTTP method of every request, see WithMethod
+	encoder    Encoder
+	tokens     TokenSource
+	inFlight   chan struct{} // request slots when WithMaxInFlight is set
+
+	bodyReadTimeout time.Duration
+
+	maxBatchBytes    int
+	observeBatchSize func(bytes int) // see SetBatchSizeObserver
+
+	probeInterval time.Duration
+	now           func() time.Time
+
+	mu           sync.Mutex
+	preferred    int       // index of the endpoint tried first
+	failedOverAt time.Time // when preferred last changed or the primary was probed
+}
+
+// Option configures optional Client behavior
+type Option func(*Client)
+
+// WithEncoder sets the encoder used for request bodies (JSONEncoder by default)
+func WithEncoder(encoder Encoder) Option {
+	return func(c *Client) {
+		c.encoder = encoder
+	}
+}
+
+// Methods accepted by WithMethod
+var allowedMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+
+// WithMethod sends requests with method instead of POST, for gateways that
+// expect PUT or PATCH for idempotent upserts. Check it with CheckMethod.
+func WithMethod(method string) Option {
+	return func(c *Client) {
+		c.method = method
+	}
+}
+
+// CheckMethod returns an error unless method is one WithMethod accepts
+func CheckMethod(method string) error {
+	for _, allowed := range allowedMethods {
+		if method == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported method %q, expected one of %s", method, strings.Join(allowedMethods, ", "))
+}
+
+// WithTransport sends requests through rt while keeping the client's other
+// defaults, such as the request timeout. Wrap http.DefaultTransport (or your
+// own) to layer tracing or logging middleware. The http.Client passed to
+// NewClientWithHTTP is copied, not modified.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		httpClient.Transport = rt
+		c.httpClient = &httpClient
+	}
+}
+
+// WithDialContext opens the client's connections with dial, for example a
+// net.Dialer with a custom Resolver or one bound to a specific egress address.
+// It clones the client's *http.Transport (http.DefaultTransport by default)
+// and has no effect when WithTransport set a RoundTripper of another type, so
+// apply it before WithTransport when wrapping.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(c *Client) {
+		c.configureTransport(func(transport *http.Transport) {
+			transport.DialContext = dial
+		})
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification, for testing
+// against endpoints with self-signed certificates only. Like WithDialContext
+// it clones the client's *http.Transport and must be applied before
+// WithTransport.
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) {
+		c.configureTransport(func(transport *http.Transport) {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+			transport.TLSClientConfig.InsecureSkipVerify = true
+		})
+	}
+}
+
+// WithProxyURL sends requests through the proxy at proxyURL instead of the
+// one named by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+// variables, which the default transport honors. A nil proxyURL keeps the
+// environment's. Like WithDialContext it must be applied before
+// WithTransport.
+func WithProxyURL(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		if proxyURL == nil {
+			return
+		}
+		c.configureTransport(func(transport *http.Transport) {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		})
+	}
+}
+
+// configureTransport applies configure to a clone of the client's
+// *http.Transport (http.DefaultTransport by default), leaving the original
+// untouched. RoundTrippers of other types, such as one set by WithTransport,
+// are left as they are.
+func (c *Client) configureTransport(configure func(transport *http.Transport)) {
+	base := c.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return
+	}
+	transport = transport.Clone()
+	configure(transport)
+
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
 }
 
 // Response represents the API response
 type Response struct {
 	StatusCode int
+	Headers    http.Header // nil if no response was received
 	Body       []byte
 	Error      error
 }
 
 // NewClient creates a new API client
-func NewClient(baseURL string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+func NewClient(baseURL string, opts ...Option) *Client {
+	return NewClientWithHTTP(baseURL, &http.Client{
+		Timeout: 30 * time.Second,
+	}, opts...)
 }
 
 // NewClientWithHTTP creates a new API client with a custom HTTP client (for testing)
-func NewClientWithHTTP(baseURL string, httpClient *http.Client) *Client {
-	return &Client{
-		baseURL:    baseURL,
-		httpClient: httpClient,
+func NewClientWithHTTP(baseURL string, httpClient *http.Client, opts ...Option) *Client {
+	c := &Client{
+		endpoints:     []string{baseURL},
+		httpClient:    httpClient,
+		method:        http.MethodPost,
+		encoder:       JSONEncoder,
+		probeInterval: DefaultPrimaryProbeInterval,
+		now:           time.Now,
+
+		bodyReadTimeout: DefaultBodyReadTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 // SendWeatherData sends weather data to the API Service
 func (c *Client) SendWeatherData(msg *validator.WeatherMessage) *Response {
-	jsonData, err := json.Marshal(msg)
+	return c.SendWeatherDataWithHeaders(msg, nil)
+}
+
+// SendWeatherDataWithHeaders sends weather data to the API Service, adding
+// the given headers to the outgoing request
+func (c *Client) SendWeatherDataWithHeaders(msg *validator.WeatherMessage, headers map[string]string) *Response {
+	return c.SendWeatherDataCtx(context.Background(), msg, headers)
+}
+
+// SendWeatherDataCtx sends weather data to the API Service like
+// SendWeatherDataWithHeaders, aborting the request when ctx is done
+func (c *Client) SendWeatherDataCtx(ctx context.Context, msg *validator.WeatherMessage, headers map[string]string) *Response {
+	return c.send(ctx, headers, msg)
+}
+
+// SendWeatherBatch sends several weather messages to the API Service in one
+// request, encoded with the client's encoder. A batch over WithMaxBatchBytes
+// is split into requests that fit, posted in order until one fails; the last
+// response is returned. If a single message is over the limit nothing is
+// posted and ErrBatchTooLarge is returned.
+func (c *Client) SendWeatherBatch(msgs []*validator.WeatherMessage, headers map[string]string) *Response {
+	var batches [][]*validator.WeatherMessage
+	batcher := c.NewBatcher()
+	for _, msg := range msgs {
+		full, err := batcher.Add(msg)
+		if errors.Is(err, ErrBatchTooLarge) {
+			return &Response{Error: fmt.Errorf("%w: a single message exceeds the %d byte limit", ErrBatchTooLarge, c.maxBatchBytes)}
+		}
+		if err != nil {
+			return &Response{Error: fmt.Errorf("failed to marshal message: %w", err)}
+		}
+		if full != nil {
+			batches = append(batches, full)
+		}
+	}
+	if last := batcher.Flush(); last != nil {
+		batches = append(batches, last)
+	}
+	if len(batches) == 0 {
+		return c.send(context.Background(), headers)
+	}
+
+	var resp *Response
+	for _, batch := range batches {
+		resp = c.send(context.Background(), headers, batch...)
+		if !resp.IsSuccess() {
+			return resp
+		}
+	}
+	return resp
+}
+
+// send encodes msgs and posts them to the API Service, failing over across
+// endpoints. The last endpoint's response is returned if none succeeds.
+// Endpoint URL placeholders are filled from the first message, whose type and
+// source also select a WithAlertsURL or WithSourceURLs endpoint when one is
+// set.
+func (c *Client) send(ctx context.Context, headers map[string]string, msgs ...*validator.WeatherMessage) *Response {
+	data, err := c.encoder.Marshal(msgs...)
 	if err != nil {
 		return &Response{Error: fmt.Errorf("failed to marshal message: %w", err)}
 	}
+	if len(msgs) > 1 {
+		if c.maxBatchBytes > 0 && len(data) > c.maxBatchBytes {
+			return &Response{Error: fmt.Errorf("%w: %d bytes, limit %d", ErrBatchTooLarge, len(data), c.maxBatchBytes)}
+		}
+		if c.observeBatchSize != nil {
+			c.observeBatchSize(len(data))
+		}
+	}
+
+	var first *validator.WeatherMessage
+	if len(msgs) > 0 {
+		first = msgs[0]
+	}
+
+	if endpoint, ok := c.routedURL(first); ok {
+		return c.request(ctx, expandURL(endpoint, first), data, headers)
+	}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseURL, bytes.NewBuffer(jsonData))
+	var resp *Response
+	for _, i := range c.endpointOrder() {
+		resp = c.request(ctx, expandURL(c.endpoints[i], first), data, headers)
+		if ctx.Err() != nil {
+			return resp
+		}
+		if !shouldFailOver(resp) {
+			c.markHealthy(i)
+			return resp
+		}
+	}
+	return resp
+}
+
+// request sends an encoded body to one endpoint with the client's method
+func (c *Client) request(ctx context.Context, url string, data []byte, headers map[string]string) *Response {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, c.method, url, bytes.NewBuffer(data))
 	if err != nil {
 		return &Response{Error: fmt.Errorf("failed to create request: %w", err)}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if c.tokens != nil {
+		if token := c.tokens.Token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	req.Header.Set("Content-Type", c.encoder.ContentType)
+
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return &Response{Error: err}
+	}
+	defer release()
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -62,15 +312,39 @@ func (c *Client) SendWeatherData(msg *validator.WeatherMessage) *Response {
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	body, err := readBody(resp.Body, c.bodyReadTimeout)
+	if errors.Is(err, errBodyReadTimeout) {
+		return &Response{Error: fmt.Errorf("failed to read response: %w", err)}
+	}
 
 	return &Response{
 		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
 		Body:       body,
 		Error:      nil,
 	}
 }
 
+// createdResource is the part of a success body that identifies what the API stored
+type createdResource struct {
+	ID string `json:"id"`
+}
+
+// ResourceID returns the id the API assigned to the stored record, taken from
+// a {"id": "..."} success body. ok is false for failed requests and for bodies
+// that aren't JSON or carry no id.
+func (r *Response) ResourceID() (id string, ok bool) {
+	if !r.IsSuccess() {
+		return "", false
+	}
+
+	var created createdResource
+	if err := json.Unmarshal(r.Body, &created); err != nil || created.ID == "" {
+		return "", false
+	}
+	return created.ID, true
+}
+
 // IsSuccess checks if the response indicates success (2xx status code)
 func (r *Response) IsSuccess() bool {
 	return r.Error == nil && r.StatusCode >= 200 && r.StatusCode < 300
@@ -1,9 +1,13 @@
 package api_client
 
 import (
+	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"queue-worker/internal/validator"
 )
@@ -12,6 +16,7 @@ import (
 // _Requirements: 2.4, 2.5, 2.6_
 
 func createTestMessage() *validator.WeatherMessage {
+	rainProbability := 30.0
 	return &validator.WeatherMessage{
 		Timestamp: "2025-12-03T14:30:00Z",
 		Location: validator.Location{
@@ -24,7 +29,7 @@ func createTestMessage() *validator.WeatherMessage {
 			Humidity:        65.0,
 			WindSpeed:       12.3,
 			Condition:       "partly_cloudy",
-			RainProbability: 30.0,
+			RainProbability: &rainProbability,
 		},
 		Source: "open-meteo",
 	}
@@ -59,6 +64,26 @@ func TestSendWeatherData_Success(t *testing.T) {
 	}
 }
 
+func TestSendWeatherDataWithHeaders_SetsHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("Expected X-Tenant header 'acme', got %q", r.Header.Get("X-Tenant"))
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected Content-Type application/json, got %s", r.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp := client.SendWeatherDataWithHeaders(createTestMessage(), map[string]string{"x-tenant": "acme"})
+
+	if !resp.IsSuccess() {
+		t.Errorf("Expected success, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+}
+
 func TestSendWeatherData_ClientError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -214,3 +239,326 @@ func TestResponse_IsServerError(t *testing.T) {
 		})
 	}
 }
+
+func TestSendWeatherData_FailsOverWhenPrimaryDown(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	primaryURL := primary.URL
+	primary.Close() // connections to the primary are refused
+
+	var secondaryCalls int
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer secondary.Close()
+
+	client := NewClient(primaryURL, WithFallbackURLs(secondary.URL))
+
+	resp := client.SendWeatherData(createTestMessage())
+	if !resp.IsSuccess() {
+		t.Fatalf("Expected failover to succeed, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+	if secondaryCalls != 1 {
+		t.Errorf("Expected 1 call to secondary, got %d", secondaryCalls)
+	}
+	if client.ActiveEndpoint() != secondary.URL {
+		t.Errorf("Expected secondary to be preferred, got %s", client.ActiveEndpoint())
+	}
+}
+
+func TestSendWeatherData_FailsOverOnServerError(t *testing.T) {
+	var primaryCalls, secondaryCalls int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer secondary.Close()
+
+	client := NewClient(primary.URL, WithFallbackURLs(secondary.URL))
+	client.SendWeatherData(createTestMessage())
+	client.SendWeatherData(createTestMessage())
+
+	// the second request goes straight to the healthy secondary
+	if primaryCalls != 1 || secondaryCalls != 2 {
+		t.Errorf("Expected 1 primary and 2 secondary calls, got %d and %d", primaryCalls, secondaryCalls)
+	}
+}
+
+func TestSendWeatherData_NoFailoverOnClientError(t *testing.T) {
+	var secondaryCalls int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls++
+	}))
+	defer secondary.Close()
+
+	client := NewClient(primary.URL, WithFallbackURLs(secondary.URL))
+	resp := client.SendWeatherData(createTestMessage())
+
+	if !resp.IsClientError() || secondaryCalls != 0 {
+		t.Errorf("Expected 4xx from primary without failover, got %d with %d secondary calls", resp.StatusCode, secondaryCalls)
+	}
+}
+
+func TestSendWeatherData_ReprobesPrimary(t *testing.T) {
+	primaryUp := false
+	var primaryCalls int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls++
+		if !primaryUp {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer secondary.Close()
+
+	now := time.Now()
+	client := NewClient(primary.URL, WithFallbackURLs(secondary.URL), WithPrimaryProbeInterval(time.Minute))
+	client.now = func() time.Time { return now }
+
+	client.SendWeatherData(createTestMessage())
+	primaryUp = true
+	client.SendWeatherData(createTestMessage())
+	if primaryCalls != 1 {
+		t.Fatalf("Expected primary not to be retried before the probe interval, got %d calls", primaryCalls)
+	}
+
+	now = now.Add(time.Minute)
+	client.SendWeatherData(createTestMessage())
+	if primaryCalls != 2 {
+		t.Errorf("Expected primary to be re-probed, got %d calls", primaryCalls)
+	}
+	if client.ActiveEndpoint() != primary.URL {
+		t.Errorf("Expected primary to be preferred again, got %s", client.ActiveEndpoint())
+	}
+}
+
+// countingTransport counts requests before passing them to the default transport
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithTransport_WrapsRequestsAndKeepsDefaults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	client := NewClient(server.URL, WithTransport(transport))
+
+	if resp := client.SendWeatherData(createTestMessage()); !resp.IsSuccess() {
+		t.Fatalf("Expected success, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+	if transport.requests != 1 {
+		t.Errorf("Expected 1 request through the custom transport, got %d", transport.requests)
+	}
+	if client.httpClient.Timeout != 30*time.Second {
+		t.Errorf("Expected default timeout to be kept, got %v", client.httpClient.Timeout)
+	}
+}
+
+func TestWithTransport_DoesNotModifyCallerClient(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Second}
+	client := NewClientWithHTTP("http://example.com", httpClient, WithTransport(&countingTransport{}))
+
+	if httpClient.Transport != nil {
+		t.Error("Expected caller's http.Client to be left unchanged")
+	}
+	if client.httpClient.Timeout != time.Second {
+		t.Errorf("Expected caller's timeout to be kept, got %v", client.httpClient.Timeout)
+	}
+}
+
+func TestWithDialContext_UsesCustomDialer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	// Resolve a name only the custom dialer knows to the test server
+	var dialed []string
+	dialer := &net.Dialer{}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return dialer.DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	client := NewClient("http://weather-api.internal/api/weather/logs", WithDialContext(dial))
+
+	if resp := client.SendWeatherData(createTestMessage()); !resp.IsSuccess() {
+		t.Fatalf("Expected success, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+	if len(dialed) != 1 || dialed[0] != "weather-api.internal:80" {
+		t.Errorf("Expected the custom dialer to be invoked once, got %v", dialed)
+	}
+}
+
+func TestWithDialContext_IgnoredForCustomTransport(t *testing.T) {
+	transport := &countingTransport{}
+	client := NewClient("http://example.com", WithTransport(transport), WithDialContext(nil))
+
+	if client.httpClient.Transport != transport {
+		t.Error("Expected a custom RoundTripper to be kept")
+	}
+}
+
+func TestWithInsecureSkipVerify_AcceptsSelfSignedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	if resp := NewClient(server.URL).SendWeatherData(createTestMessage()); resp.Error == nil {
+		t.Fatal("Expected the self-signed certificate to be rejected by default")
+	}
+
+	client := NewClient(server.URL, WithInsecureSkipVerify())
+	if resp := client.SendWeatherData(createTestMessage()); !resp.IsSuccess() {
+		t.Errorf("Expected success, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+	if tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig; tlsConfig != nil && tlsConfig.InsecureSkipVerify {
+		t.Error("Expected http.DefaultTransport to be left unchanged")
+	}
+}
+
+func TestWithProxyURL_RoutesRequestsThroughProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	client := NewClient("http://weather-api.internal/api/weather/logs", WithProxyURL(proxyURL))
+
+	if resp := client.SendWeatherData(createTestMessage()); !resp.IsSuccess() {
+		t.Fatalf("Expected success, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://weather-api.internal/api/weather/logs" {
+		t.Errorf("Expected the request to go through the proxy, got %v", proxied)
+	}
+}
+
+func TestWithProxyURL_NilKeepsTransport(t *testing.T) {
+	client := NewClient("http://example.com", WithProxyURL(nil))
+
+	if client.httpClient.Transport != nil {
+		t.Error("Expected the default transport to be kept")
+	}
+}
+
+func TestSendWeatherData_URLTemplate(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/api/weather/{source}/{city}/logs")
+	client.SendWeatherData(createTestMessage())
+
+	if path != "/api/weather/open-meteo/S%C3%A3o%20Paulo/logs" {
+		t.Errorf("Expected placeholders filled from the message, got %s", path)
+	}
+}
+
+func TestCheckURLTemplate(t *testing.T) {
+	valid := []string{
+		"http://localhost:3000/api/weather/logs",
+		"http://localhost:3000/api/weather/{source}/logs",
+		"http://localhost:3000/api/{city}/{source}",
+	}
+	for _, rawURL := range valid {
+		if err := CheckURLTemplate(rawURL); err != nil {
+			t.Errorf("Expected %s to be valid, got: %v", rawURL, err)
+		}
+	}
+
+	if err := CheckURLTemplate("http://localhost:3000/api/{region}/logs"); err == nil {
+		t.Error("Expected unknown placeholder to be rejected")
+	}
+}
+
+func TestResponse_ResourceIDOnCreated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "6650f1c2a9"}`))
+	}))
+	defer server.Close()
+
+	resp := NewClient(server.URL).SendWeatherData(createTestMessage())
+
+	id, ok := resp.ResourceID()
+	if !ok || id != "6650f1c2a9" {
+		t.Errorf("Expected resource id 6650f1c2a9, got %q (ok=%v)", id, ok)
+	}
+}
+
+func TestResponse_ResourceIDMissing(t *testing.T) {
+	testCases := []struct {
+		name string
+		resp *Response
+	}{
+		{"not JSON", &Response{StatusCode: 201, Body: []byte("Created")}},
+		{"no id", &Response{StatusCode: 201, Body: []byte(`{"status": "ok"}`)}},
+		{"empty body", &Response{StatusCode: 204}},
+		{"failed request", &Response{StatusCode: 500, Body: []byte(`{"id": "x"}`)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if id, ok := tc.resp.ResourceID(); ok {
+				t.Errorf("Expected no resource id, got %q", id)
+			}
+		})
+	}
+}
+
+func TestSendWeatherData_UsesConfiguredMethod(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Method
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			if resp := NewClient(server.URL, WithMethod(method)).SendWeatherData(createTestMessage()); !resp.IsSuccess() {
+				t.Fatalf("Expected success, got %+v", resp)
+			}
+			if got != method {
+				t.Errorf("Expected %s request, got %s", method, got)
+			}
+		})
+	}
+}
+
+func TestCheckMethod(t *testing.T) {
+	if err := CheckMethod(http.MethodPut); err != nil {
+		t.Errorf("Expected PUT to be allowed, got %v", err)
+	}
+	if err := CheckMethod(http.MethodGet); err == nil {
+		t.Error("Expected GET to be rejected")
+	}
+}
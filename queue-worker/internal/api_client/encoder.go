@@ -0,0 +1,61 @@
+package api_client
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"queue-worker/internal/validator"
+)
+
+// Encoder serializes weather messages into a request body of a given content type
+type Encoder struct {
+	ContentType string
+	Marshal     func(msgs ...*validator.WeatherMessage) ([]byte, error)
+	// Overhead returns the bytes Marshal adds around n messages beyond their
+	// sizes encoded one at a time, so a Batcher can size a batch without
+	// encoding it again. Nil means none.
+	Overhead func(n int) int
+}
+
+// JSONEncoder encodes a single message as a JSON object and several as a JSON array
+var JSONEncoder = Encoder{
+	ContentType: "application/json",
+	Marshal: func(msgs ...*validator.WeatherMessage) ([]byte, error) {
+		if len(msgs) == 1 {
+			return json.Marshal(msgs[0])
+		}
+		return json.Marshal(msgs)
+	},
+	Overhead: func(n int) int {
+		if n <= 1 {
+			return 0
+		}
+		return 2 + n - 1 // brackets and separating commas
+	},
+}
+
+// NDJSONEncoder encodes messages as newline-delimited JSON, one object per line
+var NDJSONEncoder = Encoder{
+	ContentType: "application/x-ndjson",
+	Marshal: func(msgs ...*validator.WeatherMessage) ([]byte, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, msg := range msgs {
+			if err := enc.Encode(msg); err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	},
+}
+
+// EncoderByName returns the encoder registered under name ("json" or "ndjson")
+func EncoderByName(name string) (Encoder, bool) {
+	switch name {
+	case "json":
+		return JSONEncoder, true
+	case "ndjson":
+		return NDJSONEncoder, true
+	}
+	return Encoder{}, false
+}
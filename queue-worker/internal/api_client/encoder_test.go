@@ -0,0 +1,87 @@
+package api_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"queue-worker/internal/validator"
+)
+
+// Unit tests for request body encoders
+
+func TestJSONEncoder_SingleMessage(t *testing.T) {
+	data, err := JSONEncoder.Marshal(createTestMessage())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var decoded validator.WeatherMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected a JSON object, got %s: %v", data, err)
+	}
+	if decoded.Location.City != "São Paulo" {
+		t.Errorf("Expected city 'São Paulo', got '%s'", decoded.Location.City)
+	}
+}
+
+func TestJSONEncoder_MultipleMessages(t *testing.T) {
+	data, err := JSONEncoder.Marshal(createTestMessage(), createTestMessage())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var decoded []validator.WeatherMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected a JSON array, got %s: %v", data, err)
+	}
+	if len(decoded) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(decoded))
+	}
+}
+
+func TestNDJSONEncoder_OneLinePerMessage(t *testing.T) {
+	data, err := NDJSONEncoder.Marshal(createTestMessage(), createTestMessage(), createTestMessage())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var decoded validator.WeatherMessage
+		if err := json.Unmarshal(line, &decoded); err != nil {
+			t.Errorf("Line %d is not a JSON object: %v", i, err)
+		}
+	}
+}
+
+func TestEncoderByName(t *testing.T) {
+	if enc, ok := EncoderByName("ndjson"); !ok || enc.ContentType != "application/x-ndjson" {
+		t.Errorf("Expected ndjson encoder, got %q (ok=%v)", enc.ContentType, ok)
+	}
+	if _, ok := EncoderByName("protobuf"); ok {
+		t.Error("Expected unknown encoding to be rejected")
+	}
+}
+
+func TestSendWeatherBatch_UsesEncoderContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Expected Content-Type application/x-ndjson, got %s", r.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithEncoder(NDJSONEncoder))
+	resp := client.SendWeatherBatch([]*validator.WeatherMessage{createTestMessage(), createTestMessage()}, nil)
+
+	if !resp.IsSuccess() {
+		t.Errorf("Expected success, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+}
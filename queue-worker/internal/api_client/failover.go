@@ -0,0 +1,71 @@
+package api_client
+
+import (
+	"time"
+)
+
+// DefaultPrimaryProbeInterval is how long the client stays on a fallback
+// endpoint before trying the primary again
+const DefaultPrimaryProbeInterval = 30 * time.Second
+
+// WithFallbackURLs adds endpoints tried, in order, when the primary returns a
+// server error or can't be reached
+func WithFallbackURLs(urls ...string) Option {
+	return func(c *Client) {
+		c.endpoints = append(c.endpoints, urls...)
+	}
+}
+
+// WithPrimaryProbeInterval sets how often the primary is re-probed while a
+// fallback endpoint is preferred
+func WithPrimaryProbeInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.probeInterval = interval
+	}
+}
+
+// ActiveEndpoint returns the endpoint requests currently go to first
+func (c *Client) ActiveEndpoint() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.endpoints[c.preferred]
+}
+
+// endpointOrder returns the endpoint indexes to try for one request: the
+// preferred endpoint, or the primary when it is due a re-probe, followed by
+// the others in configured order
+func (c *Client) endpointOrder() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	first := c.preferred
+	if first != 0 && c.now().Sub(c.failedOverAt) >= c.probeInterval {
+		first = 0
+		c.failedOverAt = c.now()
+	}
+
+	order := make([]int, 0, len(c.endpoints))
+	order = append(order, first)
+	for i := range c.endpoints {
+		if i != first {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// markHealthy makes endpoint i the preferred one after it handled a request
+func (c *Client) markHealthy(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i != c.preferred {
+		c.preferred = i
+		c.failedOverAt = c.now()
+	}
+}
+
+// shouldFailOver reports whether another endpoint may handle the request:
+// the endpoint couldn't be reached or failed with a server error
+func shouldFailOver(resp *Response) bool {
+	return resp.Error != nil || resp.IsServerError()
+}
@@ -0,0 +1,54 @@
+package api_client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader is the header the API echoes its request id in
+const RequestIDHeader = "X-Request-Id"
+
+// NewRequestID returns a random id for one API request, sent in
+// RequestIDHeader so the API's logs for that attempt can be matched with the
+// worker's
+func NewRequestID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// RequestID returns the id the API assigned to the request, for correlating
+// worker logs with the API's. It is empty if the API didn't send one.
+func (r *Response) RequestID() string {
+	return r.Headers.Get(RequestIDHeader)
+}
+
+// RetryAfter returns how long the API asked the client to wait before
+// retrying, from a Retry-After header in seconds or as an HTTP date. A date in
+// the past gives zero. ok is false when the header is absent or malformed.
+func (r *Response) RetryAfter() (delay time.Duration, ok bool) {
+	value := strings.TrimSpace(r.Headers.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
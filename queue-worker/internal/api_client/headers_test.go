@@ -0,0 +1,80 @@
+package api_client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Unit tests for response header accessors
+
+func TestSendWeatherData_CapturesResponseHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-42")
+		w.Header().Set("X-RateLimit-Remaining", "7")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	resp := NewClient(server.URL).SendWeatherData(createTestMessage())
+
+	if got := resp.Headers.Get("X-RateLimit-Remaining"); got != "7" {
+		t.Errorf("Expected custom header to be captured, got %q", got)
+	}
+	if got := resp.RequestID(); got != "req-42" {
+		t.Errorf("Expected request id req-42, got %q", got)
+	}
+}
+
+func TestNewRequestID_IsUnique(t *testing.T) {
+	first, second := NewRequestID(), NewRequestID()
+	if len(first) != 32 || first == second {
+		t.Errorf("Expected two distinct 32-character ids, got %q and %q", first, second)
+	}
+}
+
+func TestResponse_RequestIDWithoutResponse(t *testing.T) {
+	resp := NewClient("http://localhost:99999").SendWeatherData(createTestMessage())
+
+	if resp.Headers != nil || resp.RequestID() != "" {
+		t.Errorf("Expected no headers for a failed request, got %v", resp.Headers)
+	}
+}
+
+func TestResponse_RetryAfter(t *testing.T) {
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantMin time.Duration
+		wantMax time.Duration
+		wantOK  bool
+	}{
+		{name: "absent", value: "", wantOK: false},
+		{name: "seconds", value: "120", wantMin: 2 * time.Minute, wantMax: 2 * time.Minute, wantOK: true},
+		{name: "http date", value: future, wantMin: 55 * time.Second, wantMax: time.Minute, wantOK: true},
+		{name: "past date", value: past, wantOK: true},
+		{name: "negative", value: "-5", wantOK: false},
+		{name: "malformed", value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &Response{StatusCode: http.StatusTooManyRequests, Headers: http.Header{}}
+			if tt.value != "" {
+				resp.Headers.Set("Retry-After", tt.value)
+			}
+
+			delay, ok := resp.RetryAfter()
+			if ok != tt.wantOK {
+				t.Fatalf("Expected ok=%v, got %v", tt.wantOK, ok)
+			}
+			if delay < tt.wantMin || delay > tt.wantMax {
+				t.Errorf("Expected delay between %v and %v, got %v", tt.wantMin, tt.wantMax, delay)
+			}
+		})
+	}
+}
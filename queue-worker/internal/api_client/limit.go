@@ -0,0 +1,33 @@
+package api_client
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithMaxInFlight bounds the number of concurrent HTTP requests the client
+// makes across all goroutines. Callers beyond the limit block until a request
+// finishes or their context is done. Zero or less means no limit.
+func WithMaxInFlight(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.inFlight = make(chan struct{}, n)
+		} else {
+			c.inFlight = nil
+		}
+	}
+}
+
+// acquire waits for a request slot, returning a function that releases it
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	if c.inFlight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case c.inFlight <- struct{}{}:
+		return func() { <-c.inFlight }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a request slot: %w", ctx.Err())
+	}
+}
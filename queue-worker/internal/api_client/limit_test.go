@@ -0,0 +1,79 @@
+package api_client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Unit tests for the in-flight request limiter
+
+func TestWithMaxInFlight_CapsConcurrentRequests(t *testing.T) {
+	const limit = 3
+	var current, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithMaxInFlight(limit))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp := client.SendWeatherData(createTestMessage()); !resp.IsSuccess() {
+				t.Errorf("Expected success, got status %d, error %v", resp.StatusCode, resp.Error)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&peak); got > limit {
+		t.Errorf("Expected at most %d concurrent requests, got %d", limit, got)
+	}
+	if got := atomic.LoadInt32(&peak); got < 2 {
+		t.Errorf("Expected requests to run concurrently up to the limit, peak was %d", got)
+	}
+}
+
+func TestWithMaxInFlight_BlockedCallerHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(server.URL, WithMaxInFlight(1))
+	go client.SendWeatherData(createTestMessage())
+
+	// wait for the first request to take the only slot
+	deadline := time.Now().Add(time.Second)
+	for len(client.inFlight) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	resp := client.SendWeatherDataCtx(ctx, createTestMessage(), nil)
+
+	if resp.Error == nil {
+		t.Error("Expected blocked request to fail when its context expired")
+	}
+}
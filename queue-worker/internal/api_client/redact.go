@@ -0,0 +1,21 @@
+package api_client
+
+import "net/url"
+
+// redactURL masks the password in a URL for error messages, the same way the
+// logged configuration does. A URL that can't be parsed is masked entirely.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "****"
+	}
+	if _, hasPassword := u.User.Password(); !hasPassword {
+		return raw
+	}
+
+	// Built by hand because url.URL.String would escape the mask
+	user := url.User(u.User.Username()).String()
+	u.User = nil
+	rest := u.String()[len(u.Scheme)+len("://"):]
+	return u.Scheme + "://" + user + ":****@" + rest
+}
@@ -0,0 +1,62 @@
+package api_client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"queue-worker/internal/validator"
+)
+
+// WithSourceURLs sends messages from the mapped sources to their own URL
+// instead of the client's endpoints. Messages from other sources use the
+// primary and fallbacks as usual. Mapped URLs may use the same placeholders
+// and are not failed over; a batch is routed by its first message's source.
+func WithSourceURLs(urls map[string]string) Option {
+	return func(c *Client) {
+		c.sourceURLs = urls
+	}
+}
+
+// WithAlertsURL sends severe-weather alerts (messages of type
+// validator.TypeAlert) to alertsURL, which may use the usual placeholders, and
+// takes precedence over WithSourceURLs. Like source URLs it is not failed
+// over. Without it alerts go to the same endpoints as readings.
+func WithAlertsURL(alertsURL string) Option {
+	return func(c *Client) {
+		c.alertsURL = alertsURL
+	}
+}
+
+// CheckEndpointURL returns an error unless rawURL is an absolute http or
+// https URL whose placeholders CheckURLTemplate accepts
+func CheckEndpointURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// Not wrapped: *url.Error quotes the URL, credentials included
+		return errors.New("invalid URL: cannot be parsed")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: expected an absolute http or https URL", redactURL(rawURL))
+	}
+	return CheckURLTemplate(rawURL)
+}
+
+// routedURL returns the URL msg is routed to instead of the client's
+// endpoints, if any: the alerts URL for an alert, otherwise the URL mapped to
+// its source
+func (c *Client) routedURL(msg *validator.WeatherMessage) (string, bool) {
+	if msg.IsAlert() && c.alertsURL != "" {
+		return c.alertsURL, true
+	}
+	return c.sourceURL(msg)
+}
+
+// sourceURL returns the URL mapped to msg's source, if any
+func (c *Client) sourceURL(msg *validator.WeatherMessage) (string, bool) {
+	if msg == nil || len(c.sourceURLs) == 0 {
+		return "", false
+	}
+	endpoint, ok := c.sourceURLs[msg.Source]
+	return endpoint, ok
+}
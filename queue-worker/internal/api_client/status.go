@@ -0,0 +1,61 @@
+package api_client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Status classes say how the worker treats an API response
+const (
+	// StatusSuccess means the API accepted the message
+	StatusSuccess = "success"
+	// StatusRetryable means a later attempt may succeed
+	StatusRetryable = "retryable"
+	// StatusTerminal means the API refused the message and retrying won't
+	// change its answer
+	StatusTerminal = "terminal"
+)
+
+// StatusPolicy overrides the class of specific status codes, for API
+// deployments with quirks such as answering 409 for records that already
+// exist. Codes it doesn't list keep their default class.
+type StatusPolicy map[int]string
+
+// ParseStatusPolicy builds a StatusPolicy from status codes and class names,
+// e.g. {"409": "success"}
+func ParseStatusPolicy(raw map[string]string) (StatusPolicy, error) {
+	policy := make(StatusPolicy, len(raw))
+	for code, class := range raw {
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid status code %q", code)
+		}
+		switch class {
+		case StatusSuccess, StatusRetryable, StatusTerminal:
+		default:
+			return nil, fmt.Errorf("unknown class %q for status %d (expected %s, %s or %s)", class, status, StatusSuccess, StatusRetryable, StatusTerminal)
+		}
+		policy[status] = class
+	}
+	return policy, nil
+}
+
+// Classify returns the class of resp. By default 2xx is success, 4xx other
+// than 429 is terminal, and everything else, including failed requests, is
+// retryable.
+func (p StatusPolicy) Classify(resp *Response) string {
+	if resp.Error != nil {
+		return StatusRetryable
+	}
+	if class, ok := p[resp.StatusCode]; ok {
+		return class
+	}
+	switch {
+	case resp.IsSuccess():
+		return StatusSuccess
+	case resp.IsClientError() && resp.StatusCode != http.StatusTooManyRequests:
+		return StatusTerminal
+	}
+	return StatusRetryable
+}
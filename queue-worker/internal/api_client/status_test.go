@@ -0,0 +1,63 @@
+package api_client
+
+import (
+	"errors"
+	"testing"
+)
+
+// Unit tests for status code classification
+
+func TestStatusPolicy_DefaultClassification(t *testing.T) {
+	var policy StatusPolicy
+
+	testCases := []struct {
+		name  string
+		resp  *Response
+		class string
+	}{
+		{"created", &Response{StatusCode: 201}, StatusSuccess},
+		{"bad request", &Response{StatusCode: 400}, StatusTerminal},
+		{"conflict", &Response{StatusCode: 409}, StatusTerminal},
+		{"too many requests", &Response{StatusCode: 429}, StatusRetryable},
+		{"server error", &Response{StatusCode: 503}, StatusRetryable},
+		{"redirect", &Response{StatusCode: 302}, StatusRetryable},
+		{"connection error", &Response{Error: errors.New("connection refused")}, StatusRetryable},
+	}
+
+	for _, tc := range testCases {
+		if got := policy.Classify(tc.resp); got != tc.class {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.class, got)
+		}
+	}
+}
+
+func TestStatusPolicy_Overrides(t *testing.T) {
+	policy, err := ParseStatusPolicy(map[string]string{"409": "success", "418": "terminal", "500": "terminal"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := policy.Classify(&Response{StatusCode: 409}); got != StatusSuccess {
+		t.Errorf("Expected 409 as success, got %s", got)
+	}
+	if got := policy.Classify(&Response{StatusCode: 500}); got != StatusTerminal {
+		t.Errorf("Expected 500 as terminal, got %s", got)
+	}
+	if got := policy.Classify(&Response{StatusCode: 503}); got != StatusRetryable {
+		t.Errorf("Expected unlisted 503 to keep its default, got %s", got)
+	}
+}
+
+func TestParseStatusPolicy_RejectsInvalidEntries(t *testing.T) {
+	invalid := []map[string]string{
+		{"conflict": "success"},
+		{"99": "success"},
+		{"600": "retryable"},
+		{"409": "ignore"},
+	}
+	for _, raw := range invalid {
+		if _, err := ParseStatusPolicy(raw); err == nil {
+			t.Errorf("Expected %v to be rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package api_client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StubTransport answers every request itself with StatusCode after Latency,
+// without any network I/O. The request body is still read, so encoding cost
+// is measured as it would be against a real API.
+type StubTransport struct {
+	StatusCode int
+	Latency    time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t StubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	if t.Latency > 0 {
+		select {
+		case <-time.After(t.Latency):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	return &http.Response{
+		StatusCode: t.StatusCode,
+		Status:     http.StatusText(t.StatusCode),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// WithStub makes the client answer every request with statusCode after
+// latency instead of calling the API, for load tests and benchmarks of the
+// validation and dispatch path. Messages are not delivered anywhere.
+func WithStub(statusCode int, latency time.Duration) Option {
+	return WithTransport(StubTransport{StatusCode: statusCode, Latency: latency})
+}
@@ -0,0 +1,52 @@
+package api_client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Unit tests for the stub transport
+
+func TestWithStub_ReturnsConfiguredStatus(t *testing.T) {
+	client := NewClient("http://api.invalid/api/weather/logs", WithStub(503, 0))
+
+	resp := client.SendWeatherData(createTestMessage())
+	if resp.Error != nil || resp.StatusCode != 503 {
+		t.Errorf("Expected stubbed 503 without error, got status %d, error %v", resp.StatusCode, resp.Error)
+	}
+}
+
+func TestWithStub_AppliesLatency(t *testing.T) {
+	client := NewClient("http://api.invalid/api/weather/logs", WithStub(201, 20*time.Millisecond))
+
+	started := time.Now()
+	if resp := client.SendWeatherData(createTestMessage()); !resp.IsSuccess() {
+		t.Fatalf("Expected stubbed success, got %+v", resp)
+	}
+	if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected at least 20ms latency, got %v", elapsed)
+	}
+}
+
+func TestWithStub_LatencyHonorsContext(t *testing.T) {
+	client := NewClient("http://api.invalid/api/weather/logs", WithStub(201, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	resp := client.SendWeatherDataCtx(ctx, createTestMessage(), nil)
+	if !errors.Is(resp.Error, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", resp.Error)
+	}
+}
+
+func BenchmarkSendWeatherData_Stub(b *testing.B) {
+	client := NewClient("http://api.invalid/api/weather/logs", WithStub(201, 0))
+	msg := createTestMessage()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		client.SendWeatherData(msg)
+	}
+}
@@ -0,0 +1,41 @@
+package api_client
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"queue-worker/internal/validator"
+)
+
+// URL template placeholders filled from each message
+const (
+	PlaceholderSource = "{source}"
+	PlaceholderCity   = "{city}"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// CheckURLTemplate returns an error naming the first placeholder in rawURL
+// other than {source} and {city}
+func CheckURLTemplate(rawURL string) error {
+	for _, placeholder := range placeholderPattern.FindAllString(rawURL, -1) {
+		if placeholder != PlaceholderSource && placeholder != PlaceholderCity {
+			return fmt.Errorf("unknown placeholder %s in %q", placeholder, redactURL(rawURL))
+		}
+	}
+	return nil
+}
+
+// expandURL fills the placeholders in an endpoint from msg, escaping the
+// values as path segments. URLs without placeholders are returned unchanged.
+func expandURL(endpoint string, msg *validator.WeatherMessage) string {
+	if msg == nil || !strings.Contains(endpoint, "{") {
+		return endpoint
+	}
+	return strings.NewReplacer(
+		PlaceholderSource, url.PathEscape(msg.Source),
+		PlaceholderCity, url.PathEscape(msg.Location.City),
+	).Replace(endpoint)
+}
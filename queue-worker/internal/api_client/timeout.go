@@ -0,0 +1,24 @@
+package api_client
+
+import (
+	"context"
+	"time"
+)
+
+// requestTimeoutKey is the context key for WithRequestTimeout
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout returns a context under which each request the client
+// sends, to every endpoint it fails over to, times out after timeout. The
+// client's own timeout still applies, so only shorter timeouts take effect.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// requestContext applies the per-request timeout set on ctx, if any
+func requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
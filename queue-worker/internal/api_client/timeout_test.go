@@ -0,0 +1,47 @@
+package api_client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Unit tests for per-request timeouts
+
+func TestSendWeatherDataCtx_RequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	ctx := WithRequestTimeout(context.Background(), 20*time.Millisecond)
+	if resp := client.SendWeatherDataCtx(ctx, createTestMessage(), nil); resp.Error == nil {
+		t.Errorf("Expected the short timeout to fail the request, got %+v", resp)
+	}
+
+	if resp := client.SendWeatherDataCtx(context.Background(), createTestMessage(), nil); !resp.IsSuccess() {
+		t.Errorf("Expected the default timeout to allow the request, got %+v", resp)
+	}
+}
+
+func TestSendWeatherDataCtx_RequestTimeoutFailsOver(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer secondary.Close()
+
+	client := NewClient(primary.URL, WithFallbackURLs(secondary.URL))
+	ctx := WithRequestTimeout(context.Background(), 20*time.Millisecond)
+	if resp := client.SendWeatherDataCtx(ctx, createTestMessage(), nil); !resp.IsSuccess() {
+		t.Errorf("Expected the timed-out primary to fail over to the secondary, got %+v", resp)
+	}
+}
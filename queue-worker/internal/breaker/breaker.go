@@ -0,0 +1,72 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the circuit breaker state
+type State string
+
+const (
+	// Closed lets requests through and counts consecutive failures
+	Closed State = "closed"
+	// Open rejects requests until the open timeout elapses
+	Open State = "open"
+	// HalfOpen lets a probe through; its result closes or reopens the circuit
+	HalfOpen State = "half_open"
+)
+
+// Breaker trips open after a number of consecutive failures and closes again
+// once a request succeeds after the open timeout
+type Breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+}
+
+// New creates a closed breaker that opens after threshold consecutive failures
+func New(threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+		state:       Closed,
+	}
+}
+
+// State returns the current state, moving from Open to HalfOpen once the open
+// timeout has elapsed
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.openTimeout {
+		b.state = HalfOpen
+	}
+	return b.state
+}
+
+// RecordSuccess closes the circuit and resets the failure count
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = Closed
+	b.failures = 0
+}
+
+// RecordFailure counts a failure, opening the circuit when the threshold is
+// reached or when a half-open probe fails
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.state = Open
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,78 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+// Unit tests for the circuit breaker state machine
+
+func newTestBreaker(threshold int, openTimeout time.Duration) (*Breaker, *time.Time) {
+	now := time.Date(2025, 12, 3, 14, 30, 0, 0, time.UTC)
+	b := New(threshold, openTimeout)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	b.RecordFailure()
+	b.RecordFailure()
+	if b.State() != Closed {
+		t.Errorf("Expected closed below threshold, got %s", b.State())
+	}
+
+	b.RecordFailure()
+	if b.State() != Open {
+		t.Errorf("Expected open at threshold, got %s", b.State())
+	}
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(2, time.Minute)
+
+	b.RecordFailure()
+	b.RecordSuccess()
+	b.RecordFailure()
+
+	if b.State() != Closed {
+		t.Errorf("Expected failures to be consecutive only, got %s", b.State())
+	}
+}
+
+func TestBreaker_HalfOpenAfterTimeout(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+
+	b.RecordFailure()
+	*now = now.Add(59 * time.Second)
+	if b.State() != Open {
+		t.Errorf("Expected open before timeout, got %s", b.State())
+	}
+
+	*now = now.Add(time.Second)
+	if b.State() != HalfOpen {
+		t.Errorf("Expected half-open after timeout, got %s", b.State())
+	}
+}
+
+func TestBreaker_HalfOpenProbeResult(t *testing.T) {
+	b, now := newTestBreaker(3, time.Minute)
+	for i := 0; i < 3; i++ {
+		b.RecordFailure()
+	}
+	*now = now.Add(time.Minute)
+	b.State()
+
+	b.RecordFailure()
+	if b.State() != Open {
+		t.Errorf("Expected failed probe to reopen, got %s", b.State())
+	}
+
+	*now = now.Add(time.Minute)
+	b.State()
+	b.RecordSuccess()
+	if b.State() != Closed {
+		t.Errorf("Expected successful probe to close, got %s", b.State())
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	// loadMu serializes Load, which publishes the config file's values to
+	// lookupEnv through fileValues while it runs
+	loadMu     sync.Mutex
+	fileValues map[string]string
+)
+
+// Load loads configuration from environment variables, overlaid with the
+// KEY=VALUE lines of the file named by CONFIG_FILE, if any. The file is read
+// on every call, so a SIGHUP reload picks up edits to it; the process
+// environment can't change after startup.
+func Load() *Config {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	path := os.Getenv("CONFIG_FILE")
+	values, err := readEnvFile(path)
+	fileValues = values
+	defer func() { fileValues = nil }()
+
+	cfg := load()
+	cfg.ConfigFile = path
+	cfg.configFileErr = err
+	return cfg
+}
+
+// readEnvFile parses an env file: KEY=VALUE lines, with blank lines and
+// lines starting with # ignored and optional quotes around values. An empty
+// path reads nothing.
+func readEnvFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// lookupEnv returns a setting from the config file, falling back to the
+// process environment
+func lookupEnv(key string) (string, bool) {
+	if value, ok := fileValues[key]; ok {
+		return value, true
+	}
+	return os.LookupEnv(key)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// redactedValue replaces secrets in logged configuration
+const redactedValue = "****"
+
+// Redacted returns a copy of the config that is safe to log: passwords in
+// the RabbitMQ, API, alerts and proxy URLs and the API auth token are
+// replaced with ****
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.RabbitMQURL = redactURL(c.RabbitMQURL)
+	redacted.APIServiceURL = redactURL(c.APIServiceURL)
+	redacted.APIFallbackURLs = make([]string, len(c.APIFallbackURLs))
+	for i, fallback := range c.APIFallbackURLs {
+		redacted.APIFallbackURLs[i] = redactURL(fallback)
+	}
+	if c.APISourceURLs != nil {
+		redacted.APISourceURLs = make(map[string]string, len(c.APISourceURLs))
+		for source, apiURL := range c.APISourceURLs {
+			redacted.APISourceURLs[source] = redactURL(apiURL)
+		}
+	}
+	if c.APIAlertsURL != "" {
+		redacted.APIAlertsURL = redactURL(c.APIAlertsURL)
+	}
+	if c.APIProxyURL != "" {
+		redacted.APIProxyURL = redactURL(c.APIProxyURL)
+	}
+	if redacted.APIAuthToken != "" {
+		redacted.APIAuthToken = redactedValue
+	}
+	return &redacted
+}
+
+// String formats the redacted config
+func (c *Config) String() string {
+	return fmt.Sprintf("%+v", *c.Redacted())
+}
+
+// redactURL masks the password in a URL, keeping the user, host and path.
+// A URL that can't be parsed is masked entirely, since the password can't be
+// located in it.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	if _, hasPassword := u.User.Password(); !hasPassword {
+		return raw
+	}
+
+	// Built by hand because url.URL.String would escape the mask
+	user := url.User(u.User.Username()).String()
+	u.User = nil
+	rest := u.String()[len(u.Scheme)+len("://"):]
+	return u.Scheme + "://" + user + ":" + redactedValue + "@" + rest
+}
@@ -0,0 +1,25 @@
+package config
+
+// restartSettings are compared on reload to report changes that only take
+// effect after a restart, since the connection, queue and API client are
+// set up once at startup
+var restartSettings = []struct {
+	env   string
+	value func(c *Config) string
+}{
+	{"RABBITMQ_URL", func(c *Config) string { return c.RabbitMQURL }},
+	{"RABBITMQ_QUEUE", func(c *Config) string { return c.QueueName }},
+	{"API_SERVICE_URL", func(c *Config) string { return c.APIServiceURL }},
+}
+
+// RestartRequired returns the environment variables of the settings that
+// differ in next but can't be applied without a restart
+func (c *Config) RestartRequired(next *Config) []string {
+	var changed []string
+	for _, setting := range restartSettings {
+		if setting.value(c) != setting.value(next) {
+			changed = append(changed, setting.env)
+		}
+	}
+	return changed
+}
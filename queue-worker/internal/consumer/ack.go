@@ -0,0 +1,43 @@
+package consumer
+
+import (
+	"sync/atomic"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ack acknowledges a successfully processed delivery. In BatchAck mode the
+// ack is deferred and later sent with multiple=true for the highest tag, once
+// BatchAckSize deliveries are pending or flushAcks is called. It reports
+// whether the ack was deferred.
+func (c *Consumer) ack(delivery amqp.Delivery) bool {
+	if c.config.BatchAckSize <= 1 || atomic.LoadInt64(&c.deferred) > 0 {
+		// A multiple=true ack would also settle a delivery awaiting requeue
+		delivery.Ack(false)
+		return false
+	}
+
+	c.pendingAck = &delivery
+	c.pendingCount++
+	if c.pendingCount >= c.config.BatchAckSize {
+		c.flushAcks()
+	}
+	return true
+}
+
+// flushAcks acknowledges every pending delivery with a single multiple=true ack
+func (c *Consumer) flushAcks() {
+	if c.pendingAck == nil {
+		return
+	}
+
+	if err := c.pendingAck.Ack(true); err != nil {
+		c.logger.Error("Failed to acknowledge message batch", map[string]interface{}{
+			"error":        err.Error(),
+			"delivery_tag": c.pendingAck.DeliveryTag,
+			"count":        c.pendingCount,
+		})
+	}
+	c.pendingAck = nil
+	c.pendingCount = 0
+}
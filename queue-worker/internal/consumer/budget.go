@@ -0,0 +1,50 @@
+package consumer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// retryBudget is a token bucket shared by every message's retries, so a
+// broad API outage can't multiply retry traffic with message volume. It
+// refills at rate tokens per second and holds up to one second's worth.
+type retryBudget struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRetryBudget(perSecond float64) *retryBudget {
+	burst := math.Max(1, math.Ceil(perSecond))
+	return &retryBudget{
+		rate:   perSecond,
+		burst:  burst,
+		now:    time.Now,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// allow takes a token for one retry, reporting false when the budget is
+// exhausted. A nil budget is unlimited.
+func (b *retryBudget) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,76 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the global retry budget
+
+func TestRetryBudget_RefillsAtRate(t *testing.T) {
+	now := time.Date(2025, 12, 3, 12, 0, 0, 0, time.UTC)
+	budget := newRetryBudget(2)
+	budget.now = func() time.Time { return now }
+	budget.last = now
+
+	if !budget.allow() || !budget.allow() {
+		t.Fatal("Expected a full bucket to allow 2 retries")
+	}
+	if budget.allow() {
+		t.Error("Expected the budget to be exhausted")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if !budget.allow() {
+		t.Error("Expected one retry after half a second at 2/s")
+	}
+	if budget.allow() {
+		t.Error("Expected the budget to be exhausted again")
+	}
+}
+
+func TestRetryBudget_NilIsUnlimited(t *testing.T) {
+	var budget *retryBudget
+	for i := 0; i < 100; i++ {
+		if !budget.allow() {
+			t.Fatal("Expected a nil budget to always allow")
+		}
+	}
+}
+
+func TestSendWithRetry_FailsFastWhenBudgetExhausted(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	log := logger.New("test")
+	cfg := createTestConfig(server.URL)
+	cfg.RetryDelay = time.Millisecond
+	cfg.RetryBudgetPerSecond = 0.001 // one retry, then nothing for the test's lifetime
+	cons := New(cfg, api_client.NewClient(server.URL), log)
+
+	cons.ProcessSingleMessage(createValidMessageJSON())
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected the first message to use the single retry, got %d requests", got)
+	}
+
+	cons.ProcessSingleMessage(createValidMessageJSON())
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("Expected the second message to fail fast after one request, got %d requests", got)
+	}
+	if got := cons.metrics.retryBudgetExhausted.Value(); got != 2 {
+		t.Errorf("Expected 2 exhausted budget hits, got %v", got)
+	}
+	if !log.HasLogWithMessage("Retry budget exhausted, failing fast") {
+		t.Error("Expected a log when the budget is hit")
+	}
+}
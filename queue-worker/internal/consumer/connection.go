@@ -0,0 +1,235 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Ready reports whether the consumer holds a usable RabbitMQ connection
+func (c *Consumer) Ready() bool {
+	return atomic.LoadInt32(&c.connected) == 1
+}
+
+// Readiness failures reported by CheckReady
+var (
+	errNotConnected  = errors.New("not connected to RabbitMQ")
+	errNotSubscribed = errors.New("not consuming from the queue")
+)
+
+// CheckReady returns nil when the consumer is connected, registered as a
+// consumer and the queue still exists, which catches a worker that connected
+// but never consumes. The queue is declared passively on a channel of its
+// own, since the broker closes the channel when the queue is missing. With
+// ReadyAfterFirstMessage it also waits for the first message to be
+// processed, confirming the flow end to end.
+func (c *Consumer) CheckReady() error {
+	if !c.Ready() {
+		return errNotConnected
+	}
+	if atomic.LoadInt32(&c.subscribed) == 0 {
+		return errNotSubscribed
+	}
+	if err := c.queueProbe(); err != nil {
+		return fmt.Errorf("queue %s: %w", c.config.QueueName, err)
+	}
+	if c.config.ReadyAfterFirstMessage && !c.FirstMessageProcessed() {
+		return errNoMessageYet
+	}
+	return nil
+}
+
+// probeQueue declares the queue passively on a short-lived channel
+func (c *Consumer) probeQueue() error {
+	_, err := c.declarePassive()
+	return err
+}
+
+// declarePassive declares the queue passively on a short-lived channel, so a
+// missing queue, which makes the broker close the channel, never takes the
+// consuming channel down with it
+func (c *Consumer) declarePassive() (amqp.Queue, error) {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return amqp.Queue{}, errNoChannel
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+	defer ch.Close()
+
+	return ch.QueueDeclarePassive(
+		c.config.QueueName,
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		queueArguments(c.config.QueueType),
+	)
+}
+
+// setConnected updates readiness and the connection_up gauge together
+func (c *Consumer) setConnected(up bool) {
+	if up {
+		atomic.StoreInt32(&c.connected, 1)
+		c.metrics.connectionUp.Set(1)
+		return
+	}
+	atomic.StoreInt32(&c.connected, 0)
+	c.metrics.connectionUp.Set(0)
+}
+
+// ConnectWithRetry connects to RabbitMQ, making up to ConnectAttempts
+// attempts RetryDelay apart. Each attempt waits a random ReconnectJitter
+// first so replicas starting together don't all hit the broker at once.
+func (c *Consumer) ConnectWithRetry() error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		time.Sleep(c.reconnectJitter())
+		if err = c.Connect(); err == nil || attempt >= c.config.ConnectAttempts {
+			return err
+		}
+		time.Sleep(c.retryDelay())
+	}
+}
+
+// reconnectJitter returns a random delay below ReconnectJitter, or zero when
+// jitter is disabled
+func (c *Consumer) reconnectJitter() time.Duration {
+	if c.config.ReconnectJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(c.config.ReconnectJitter)))
+}
+
+// watchConnection marks the consumer down as soon as conn closes with an
+// error. A graceful Close doesn't notify, so it isn't treated as an outage.
+func (c *Consumer) watchConnection(conn *amqp.Connection) {
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if err, ok := <-closed; ok && err != nil {
+			c.connectionLost(err)
+		}
+	}()
+}
+
+// connectionLost records the start of an outage
+func (c *Consumer) connectionLost(err *amqp.Error) {
+	c.mu.Lock()
+	if c.downSince.IsZero() {
+		c.downSince = time.Now()
+	}
+	c.mu.Unlock()
+
+	c.setConnected(false)
+	c.logger.Warn("RabbitMQ connection lost", amqpErrorContext(err))
+}
+
+// watchChannel logs the broker's reason when ch closes with an error, such
+// as PRECONDITION_FAILED for a queue redeclared with different arguments.
+// Recovery is left to the delivery loop, which reopens just the channel
+// while the connection is still up.
+func (c *Consumer) watchChannel(ch *amqp.Channel) {
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if err, ok := <-closed; ok && err != nil {
+			c.logger.Warn("RabbitMQ channel closed", amqpErrorContext(err))
+		}
+	}()
+}
+
+// amqpErrorContext describes an AMQP error for logs, with the reply code and
+// reason operators need to fix a mismatched declaration
+func amqpErrorContext(err *amqp.Error) map[string]interface{} {
+	return map[string]interface{}{
+		"error":            err.Error(),
+		"code":             err.Code,
+		"reason":           err.Reason,
+		"server_initiated": err.Server,
+	}
+}
+
+// recoverConnection restores the channel after the delivery loop lost it,
+// dialing a new connection first if the old one is gone. Attempts are spread
+// by a random ReconnectJitter and retried every RetryDelay; it gives up only
+// when the consumer is shutting down.
+func (c *Consumer) recoverConnection() bool {
+	c.mu.Lock()
+	if c.downSince.IsZero() {
+		c.downSince = time.Now()
+	}
+	c.mu.Unlock()
+	c.setConnected(false)
+
+	for !c.stopping() {
+		time.Sleep(c.reconnectJitter())
+		reconnected := c.conn == nil || c.conn.IsClosed()
+		var err error
+		if reconnected {
+			err = c.Connect()
+		} else {
+			err = c.reopenChannel()
+		}
+		if err == nil {
+			c.markRecovered(reconnected)
+			return true
+		}
+		time.Sleep(c.retryDelay())
+	}
+	return false
+}
+
+// reopenChannel opens a new channel on the existing connection
+func (c *Consumer) reopenChannel() error {
+	ch, err := c.conn.Channel()
+	if err != nil {
+		c.logger.Error("Failed to reopen channel", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+
+	if err := c.usePublisher(ch); err != nil {
+		ch.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	c.channel = ch
+	c.mu.Unlock()
+	c.watchChannel(ch)
+	return nil
+}
+
+// markRecovered counts a successful recovery and logs how long the consumer
+// was down, so flapping connections can be alerted on
+func (c *Consumer) markRecovered(reconnected bool) {
+	if reconnected {
+		c.metrics.reconnects.Inc()
+	}
+	c.metrics.channelReopens.Inc()
+	c.setConnected(true)
+
+	c.mu.Lock()
+	downtime := time.Since(c.downSince)
+	c.downSince = time.Time{}
+	c.mu.Unlock()
+
+	c.logger.Info("Recovered RabbitMQ connection", map[string]interface{}{
+		"reconnected": reconnected,
+		"downtime_ms": downtime.Milliseconds(),
+	})
+}
+
+// stopping reports whether Shutdown has been called
+func (c *Consumer) stopping() bool {
+	return atomic.LoadInt32(&c.shuttingDown) == 1
+}
@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the broker connection settings
+
+func TestDialConfig_UsesHeartbeatAndLocale(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.Heartbeat = 25 * time.Second
+	cfg.Locale = "pt_BR"
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+
+	dial := cons.dialConfig()
+	if dial.Heartbeat != 25*time.Second || dial.Locale != "pt_BR" {
+		t.Errorf("Expected 25s heartbeat and pt_BR locale, got %v and %q", dial.Heartbeat, dial.Locale)
+	}
+}
+
+func TestApplyPrefetch_UnlimitedLeavesChannelAlone(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.PrefetchGlobal = true
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+
+	// No channel is open, so setting Qos would panic
+	if err := cons.applyPrefetch(); err != nil {
+		t.Errorf("Expected no prefetch to be set, got %v", err)
+	}
+}
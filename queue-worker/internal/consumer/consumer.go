@@ -1,12 +1,24 @@
 package consumer
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"queue-worker/internal/api_client"
+	"queue-worker/internal/breaker"
 	"queue-worker/internal/config"
+	"queue-worker/internal/dedup"
+	"queue-worker/internal/enrich"
 	"queue-worker/internal/logger"
+	"queue-worker/internal/metrics"
+	"queue-worker/internal/spool"
+	"queue-worker/internal/transform"
 	"queue-worker/internal/validator"
 )
 
@@ -15,8 +27,59 @@ type Consumer struct {
 	config    *config.Config
 	conn      *amqp.Connection
 	channel   *amqp.Channel
-	apiClient *api_client.Client
-	logger    *logger.Logger
+	publisher Publisher
+	sink      Publisher // secondary sink for accepted messages, nil if disabled
+	// sinkPending holds hashes of bodies whose SinkRequired publish failed
+	sinkPending *dedup.Store
+	apiClient   *api_client.Client
+	logger      *logger.Logger
+	registry    *metrics.Registry
+	metrics     *consumerMetrics
+	validator   *validator.Validator
+	breaker     *breaker.Breaker
+	spool       *spool.Spool
+	regions     enrich.RegionLookup
+	dedup       *dedup.Store  // nil unless DedupCacheSize is set
+	ordering    *cityClock    // nil unless OrderingCheckCities is set
+	shapes      *shapeTracker // nil unless SchemaFingerprintSample is set
+
+	transformer transform.Transformer
+
+	settingsMu  sync.RWMutex // guards the retry policy and the config's retry settings
+	retryPolicy RetryPolicy
+	customRetry bool         // retryPolicy was set with SetRetryPolicy
+	retryBudget *retryBudget // nil unless RetryBudgetPerSecond is set
+
+	statuses   api_client.StatusPolicy // overrides how API statuses are classified
+	queueDepth func() (int, error)     // messages ready in the queue, for drain mode
+	queueProbe func() error            // checks the queue exists, for readiness
+	middleware []Middleware            // outermost first
+	chain      Handler
+
+	consumerTag  string
+	done         chan struct{} // closed when the Start delivery loop exits
+	stopped      chan struct{} // closed when stop is first called
+	inFlight     int64         // messages currently being processed
+	deferred     int64         // deliveries requeueLater or a hold has yet to nack
+	holding      int32         // deliveries held by holdAndRequeue
+	connected    int32         // 1 while the connection and channel are usable
+	subscribed   int32         // 1 while the broker consumer is registered
+	shuttingDown int32         // 1 once Shutdown has been called
+
+	lastFailedStatus int32 // status of the last failed send, for pause logs
+
+	started     time.Time // when the consumer was created, for Stats uptime
+	lastSuccess int64     // Unix nanoseconds of the last message the API accepted
+	lastFailure int64     // Unix nanoseconds of the last message the API didn't
+	// firstMessage is the Unix nanoseconds when the first delivery finished
+	// processing, zero until then
+	firstMessage int64
+
+	mu        sync.Mutex // guards conn, channel and downSince across goroutines
+	downSince time.Time  // when the current outage started, zero if none
+
+	pendingAck   *amqp.Delivery // highest successful delivery not yet acked in BatchAck mode
+	pendingCount int            // deliveries covered by pendingAck
 }
 
 // MessageHandler is a function type for handling messages
@@ -24,33 +87,94 @@ type MessageHandler func(delivery amqp.Delivery) bool
 
 // New creates a new Consumer instance
 func New(cfg *config.Config, apiClient *api_client.Client, log *logger.Logger) *Consumer {
-	return &Consumer{
-		config:    cfg,
-		apiClient: apiClient,
-		logger:    log,
+	registry := metrics.NewRegistry()
+	hostname, _ := os.Hostname()
+	c := &Consumer{
+		config:      cfg,
+		apiClient:   apiClient,
+		logger:      log,
+		registry:    registry,
+		metrics:     newConsumerMetrics(registry),
+		validator:   validator.New(cfg.ValidatorOptions()),
+		breaker:     breaker.New(cfg.BreakerThreshold, cfg.BreakerOpenTimeout),
+		regions:     enrich.NoopLookup{},
+		transformer: transform.Identity{},
+		statuses:    cfg.StatusPolicy(),
+		consumerTag: fmt.Sprintf("queue-worker-%s-%d", hostname, os.Getpid()),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		started:     time.Now(),
+	}
+	if cfg.DedupCacheSize > 0 {
+		c.dedup = dedup.New(cfg.DedupCacheSize)
+	}
+	if cfg.RetryBudgetPerSecond > 0 {
+		c.retryBudget = newRetryBudget(cfg.RetryBudgetPerSecond)
+	}
+	if cfg.SchemaFingerprintSample > 0 {
+		c.shapes = newShapeTracker(cfg.SchemaFingerprintSample)
+	}
+	if cfg.OrderingCheckCities > 0 {
+		c.ordering = newCityClock(cfg.OrderingCheckCities)
+	}
+	if cfg.SinkExchange != "" {
+		c.sink = &sinkPublisher{conn: c.currentConn, timeout: cfg.PublishConfirmTimeout}
+		if cfg.SinkRequired {
+			c.sinkPending = dedup.New(sinkPendingCapacity)
+		}
+	}
+	c.retryPolicy = DefaultRetryPolicy{MaxAttempts: cfg.RetryAttempts, Delay: cfg.RetryDelay, Statuses: c.statuses}
+	c.queueDepth = c.inspectQueue
+	c.queueProbe = c.probeQueue
+	apiClient.SetBatchSizeObserver(func(bytes int) {
+		c.metrics.batchBytes.Observe(float64(bytes))
+	})
+	c.middleware = []Middleware{c.loggingMiddleware, c.metricsMiddleware}
+	c.buildChain()
+	return c
+}
+
+// dialConfig returns the connection settings Connect negotiates with the broker
+func (c *Consumer) dialConfig() amqp.Config {
+	return amqp.Config{
+		Heartbeat: c.config.Heartbeat,
+		Locale:    c.config.Locale,
 	}
 }
 
 // Connect establishes connection to RabbitMQ
 func (c *Consumer) Connect() error {
-	var err error
-	c.conn, err = amqp.Dial(c.config.RabbitMQURL)
+	conn, err := amqp.DialConfig(c.config.RabbitMQURL, c.dialConfig())
 	if err != nil {
 		c.logger.Error("Failed to connect to RabbitMQ", map[string]interface{}{
 			"error": err.Error(),
-			"url":   c.config.RabbitMQURL,
+			"url":   c.config.Redacted().RabbitMQURL,
 		})
 		return err
 	}
 
-	c.channel, err = c.conn.Channel()
+	ch, err := conn.Channel()
 	if err != nil {
 		c.logger.Error("Failed to open channel", map[string]interface{}{
 			"error": err.Error(),
 		})
+		conn.Close()
 		return err
 	}
 
+	if err := c.usePublisher(ch); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.channel = ch
+	c.mu.Unlock()
+	c.watchConnection(conn)
+	c.watchChannel(ch)
+	c.setConnected(true)
+
 	c.logger.Info("Connected to RabbitMQ", map[string]interface{}{
 		"queue": c.config.QueueName,
 	})
@@ -58,16 +182,29 @@ func (c *Consumer) Connect() error {
 	return nil
 }
 
-
-// DeclareQueue declares the queue if it doesn't exist
+// DeclareQueue declares the queue if it doesn't exist. With SkipDeclare it
+// only checks that the queue exists, leaving its arguments to whoever
+// provisioned it; the check runs on a short-lived channel, since a missing
+// queue closes the channel it is checked on.
 func (c *Consumer) DeclareQueue() error {
+	if c.config.SkipDeclare {
+		if err := c.queueProbe(); err != nil {
+			c.logger.Error("Queue not found or not accessible", map[string]interface{}{
+				"error": err.Error(),
+				"queue": c.config.QueueName,
+			})
+			return err
+		}
+		return nil
+	}
+
 	_, err := c.channel.QueueDeclare(
 		c.config.QueueName,
-		true,  // durable
+		true,  // durable, as quorum queues require
 		false, // delete when unused
 		false, // exclusive
 		false, // no-wait
-		nil,   // arguments
+		queueArguments(c.config.QueueType),
 	)
 	if err != nil {
 		c.logger.Error("Failed to declare queue", map[string]interface{}{
@@ -79,122 +216,485 @@ func (c *Consumer) DeclareQueue() error {
 	return nil
 }
 
-// Start begins consuming messages from the queue
-func (c *Consumer) Start() error {
-	if err := c.DeclareQueue(); err != nil {
+// applyPrefetch sets the channel's prefetch limit. It runs before every
+// Consume call, since a reopened channel starts without one.
+func (c *Consumer) applyPrefetch() error {
+	if c.config.PrefetchCount <= 0 {
+		return nil
+	}
+
+	if err := c.channel.Qos(c.config.PrefetchCount, 0, c.config.PrefetchGlobal); err != nil {
+		c.logger.Error("Failed to set prefetch", map[string]interface{}{
+			"error":          err.Error(),
+			"prefetch_count": c.config.PrefetchCount,
+			"global":         c.config.PrefetchGlobal,
+		})
 		return err
 	}
+	return nil
+}
+
+// queueArguments returns the declare arguments for a queue type. A classic
+// queue is declared without arguments, as before queue types were supported,
+// so existing queues keep matching their declaration.
+func queueArguments(queueType string) amqp.Table {
+	if queueType == "" || queueType == config.QueueTypeClassic {
+		return nil
+	}
+	return amqp.Table{"x-queue-type": queueType}
+}
+
+// consumerArguments returns the Consume arguments for a consumer priority.
+// Zero sends none, so the broker keeps round-robin between consumers.
+func consumerArguments(priority int) amqp.Table {
+	if priority == 0 {
+		return nil
+	}
+	return amqp.Table{"x-priority": int32(priority)}
+}
+
+// Start consumes messages from the queue until ctx is canceled or Shutdown is
+// called, then cancels the broker consumer and returns once the deliveries
+// already received have been processed. If the channel or connection is
+// lost, including to a channel error while subscribing, it recovers and
+// resumes consuming.
+func (c *Consumer) Start(ctx context.Context) error {
+	defer close(c.done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.stop()
+		case <-c.done:
+		}
+	}()
+	if c.spool != nil {
+		go c.flushSpool()
+	}
+	if c.config.DrainAndExit {
+		go c.watchDrain(ctx)
+	}
+
+	for {
+		if ctx.Err() != nil || c.stopping() {
+			return nil
+		}
+
+		msgs, err := c.consume()
+		if err != nil {
+			var amqpErr *amqp.Error
+			if !errors.As(err, &amqpErr) || amqpErr.Code == amqp.NotFound {
+				// A missing queue won't appear by reconnecting
+				return err
+			}
+			// A channel error such as PRECONDITION_FAILED closes only the
+			// channel; wait before reopening it so a persistent mismatch
+			// doesn't spin against the broker
+			time.Sleep(c.retryDelay())
+			if c.stopping() || !c.recoverConnection() {
+				return nil
+			}
+			continue
+		}
+		if c.stopping() {
+			// stop ran before the consumer was registered, so cancel it here
+			c.cancelConsumer()
+		}
+
+		atomic.StoreInt32(&c.subscribed, 1)
+		for msg := range msgs {
+			if !c.waitWhilePaused() {
+				// shutting down: leave the message for another consumer
+				msg.Nack(false, true)
+				continue
+			}
+			atomic.AddInt64(&c.inFlight, 1)
+			c.processMessage(msg)
+			if len(msgs) == 0 {
+				// caught up: don't hold acks while waiting for more deliveries
+				c.flushAcks()
+			}
+			atomic.AddInt64(&c.inFlight, -1)
+		}
+		atomic.StoreInt32(&c.subscribed, 0)
+		c.flushAcks()
+
+		if c.stopping() || !c.recoverConnection() {
+			return nil
+		}
+	}
+}
+
+// consume declares the queue and registers the consumer on the current channel
+func (c *Consumer) consume() (<-chan amqp.Delivery, error) {
+	if err := c.DeclareQueue(); err != nil {
+		return nil, err
+	}
+	if err := c.applyPrefetch(); err != nil {
+		return nil, err
+	}
 
 	msgs, err := c.channel.Consume(
 		c.config.QueueName,
-		"",    // consumer tag
+		c.consumerTag,
 		false, // auto-ack
 		false, // exclusive
 		false, // no-local
 		false, // no-wait
-		nil,   // args
+		consumerArguments(c.config.ConsumerPriority),
 	)
 	if err != nil {
 		c.logger.Error("Failed to register consumer", map[string]interface{}{
 			"error": err.Error(),
 		})
-		return err
+		return nil, err
 	}
 
 	c.logger.Info("Started consuming messages", map[string]interface{}{
-		"queue": c.config.QueueName,
+		"queue":    c.config.QueueName,
+		"priority": c.config.ConsumerPriority,
 	})
+	return msgs, nil
+}
+
+// Shutdown stops receiving new deliveries and waits for the delivery loop to
+// finish the messages already received. If ctx expires first, it reports
+// timedOut along with the number of messages still being processed, which may
+// be zero when the loop is blocked elsewhere (a retry delay, a reconnect).
+func (c *Consumer) Shutdown(ctx context.Context) (stuck int, timedOut bool) {
+	c.stop()
 
-	for msg := range msgs {
-		c.processMessage(msg)
+	select {
+	case <-c.done:
+		return 0, false
+	case <-ctx.Done():
+		return int(atomic.LoadInt64(&c.inFlight)), true
 	}
+}
 
-	return nil
+// stop marks the consumer as shutting down and cancels the broker consumer,
+// so the delivery loop drains and exits. Later calls do nothing.
+func (c *Consumer) stop() {
+	if atomic.CompareAndSwapInt32(&c.shuttingDown, 0, 1) {
+		close(c.stopped)
+		c.cancelConsumer()
+	}
+}
+
+// cancelConsumer asks the broker to stop sending deliveries to this consumer
+func (c *Consumer) cancelConsumer() {
+	c.mu.Lock()
+	ch := c.channel
+	c.mu.Unlock()
+	if ch == nil {
+		return
+	}
+
+	// Cancel waits for the broker; don't let it hold up a shutdown deadline
+	go func() {
+		if err := ch.Cancel(c.consumerTag, false); err != nil {
+			c.logger.Warn("Failed to cancel consumer", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
 }
 
 // processMessage handles a single message
 func (c *Consumer) processMessage(delivery amqp.Delivery) {
+	outcome := &messageOutcome{
+		deliveryTag: delivery.DeliveryTag,
+		started:     time.Now(),
+		headers:     c.outcomeHeaders(delivery.Headers),
+	}
+	defer c.finishOutcome(outcome)
+	defer c.recoverPanic(delivery, outcome)
+
+	// Any settlement other than a batched ack ends the current batch, so a
+	// batch never acknowledges past a message that was handled differently
+	batched := false
+	defer func() {
+		if !batched {
+			c.flushAcks()
+		}
+	}()
+
 	c.logger.Info("Processing message", map[string]interface{}{
 		"delivery_tag": delivery.DeliveryTag,
 	})
 
-	// Validate message
-	msg, err := validator.ValidateMessage(delivery.Body)
+	if c.oversize(delivery) {
+		outcome.outcome = c.deadLetter(delivery, DLQReasonOversize, "")
+		return
+	}
+
+	body, err := decodeBody(delivery, c.decodedLimit())
+	if errors.Is(err, errDecodedTooLarge) {
+		c.logger.Warn("Message exceeds size limit", map[string]interface{}{
+			"delivery_tag": delivery.DeliveryTag,
+			"limit_bytes":  c.decodedLimit(),
+			"decoded":      true,
+		})
+		outcome.outcome = c.deadLetter(delivery, DLQReasonOversize, "")
+		return
+	}
 	if err != nil {
-		c.logger.Error("Message validation failed", map[string]interface{}{
+		c.logger.Error("Failed to decode message body", map[string]interface{}{
 			"error":        err.Error(),
 			"delivery_tag": delivery.DeliveryTag,
 		})
-		// Nack without requeue for invalid messages
-		delivery.Nack(false, false)
+		c.recordValidationFailure(err)
+		outcome.outcome = c.deadLetter(delivery, DLQReasonDecode, "")
 		return
 	}
 
-	// Send to API with retry
-	success := c.sendWithRetry(msg)
+	if lines, ok := c.splitNDJSON(body); ok {
+		outcome.outcome, batched = c.processLines(delivery, lines)
+		return
+	}
 
-	if success {
-		c.logger.Info("Message processed successfully", map[string]interface{}{
-			"delivery_tag": delivery.DeliveryTag,
-			"timestamp":    msg.Timestamp,
-			"city":         msg.Location.City,
-		})
+	result := c.chain(context.Background(), &Message{
+		DeliveryTag: delivery.DeliveryTag,
+		Body:        body,
+		Headers:     c.forwardHeaders(delivery.Headers),
+		Timestamp:   delivery.Timestamp,
+		Priority:    c.messagePriority(delivery.Headers),
+		Publisher:   c.messagePublisher(delivery),
+	})
+	if result.Duplicate {
 		delivery.Ack(false)
-	} else {
-		c.logger.Error("Failed to send message to API after retries", map[string]interface{}{
-			"delivery_tag": delivery.DeliveryTag,
-		})
+		outcome.outcome = outcomeAck
+		return
+	}
+	if result.ValidationErr != nil {
+		outcome.outcome = c.settleRejected(delivery, result.ValidationErr)
+		return
+	}
+
+	msg := result.Parsed
+	outcome.validated = true
+	outcome.schemaVersion = msg.Version()
+	outcome.city = msg.Location.City
+	outcome.source = msg.Source
+	outcome.attempts = result.Attempts
+	outcome.statusCode = result.StatusCode
+	outcome.requestID = result.AttemptID
+	outcome.outcome, batched = c.settleHandled(delivery, result)
+}
+
+// settleRejected settles a delivery whose body failed validation and
+// returns its outcome
+func (c *Consumer) settleRejected(delivery amqp.Delivery, validationErr error) string {
+	field, code := rejectionReason(validationErr)
+	switch code {
+	case validator.CodeUnsupported:
+		// Old-format messages are kept for migration rather than dropped
+		return c.deadLetter(delivery, DLQReasonValidation, field)
+	case validator.CodeSchemaUnavailable:
+		// The schema registry is down, not the message invalid: wait
+		// before requeueing so every message doesn't spin through
+		c.requeueLater(delivery, c.retryDelay())
+		return outcomeNackRequeue
+	}
+	if c.config.RequeueInvalidOnce {
+		if settled, ok := c.requeueInvalid(delivery); ok {
+			return settled
+		}
+	}
+	if settled, ok := c.settleInvalid(delivery, validationErr); ok {
+		return settled
+	}
+	// Nack without requeue for invalid messages
+	delivery.Nack(false, false)
+	return outcomeNackDrop
+}
+
+// settleHandled settles a delivery whose body passed validation, according
+// to how handling it went. It returns the outcome and whether the ack was
+// batched.
+func (c *Consumer) settleHandled(delivery amqp.Delivery, result *Result) (outcome string, batched bool) {
+	switch {
+	case result.Expired:
+		return c.deadLetter(delivery, DLQReasonExpired, ""), false
+	case result.Spooled:
+		delivery.Ack(false)
+		return outcomeAck, false
+	case result.SinkFailed:
+		// Only the sink publish is retried on redelivery
+		c.requeueAfterDelay(delivery)
+		return outcomeNackRequeue, false
+	case result.Success:
+		return outcomeAck, c.ack(delivery)
+	case result.Rejected && c.config.DeadLetterQueue != "":
+		// Retrying won't change the API's answer
+		return c.deadLetter(delivery, DLQReasonClientError, ""), false
+	case c.shouldHold(result.StatusCode):
+		c.holdAndRequeue(delivery)
+		return outcomeNackRequeue, false
+	case c.config.MaxRetries > 0:
+		return c.requeueOrDeadLetter(delivery), false
+	default:
 		// Nack with requeue for API failures
-		delivery.Nack(false, true)
+		c.requeueAfterDelay(delivery)
+		return outcomeNackRequeue, false
 	}
 }
 
+// forwardHeaders extracts the allowlisted delivery headers to pass on to the API
+func (c *Consumer) forwardHeaders(table amqp.Table) map[string]string {
+	if len(c.config.ForwardHeaders) == 0 || len(table) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string)
+	for _, key := range c.config.ForwardHeaders {
+		if value, ok := table[key]; ok && value != nil {
+			headers[key] = fmt.Sprint(value)
+		}
+	}
+	return headers
+}
 
-// sendWithRetry attempts to send the message to the API with retries
-func (c *Consumer) sendWithRetry(msg *validator.WeatherMessage) bool {
-	for attempt := 1; attempt <= c.config.RetryAttempts; attempt++ {
+// sendResult summarizes the API calls made for one message
+type sendResult struct {
+	success    bool
+	attempts   int
+	statusCode int    // status of the last response, 0 if none was received
+	resourceID string // id the API assigned to the record, if it returned one
+	requestID  string // id the API assigned to the last request, if it echoed one
+	attemptID  string // X-Request-Id the worker sent with the last request
+	rejected   bool   // the API refused the message with a client error that isn't retried
+	err        error  // ctx.Err() if the send was cancelled before finishing
+}
+
+// sendWithRetry attempts to send the message to the API with retries,
+// recording the result in the circuit breaker. Client errors show the API is
+// reachable, so they count as successes for the breaker; cancellation says
+// nothing about the API, so it isn't recorded.
+func (c *Consumer) sendWithRetry(ctx context.Context, msg *validator.WeatherMessage, headers map[string]string) sendResult {
+	result := c.sendAttempts(ctx, msg, headers)
+	if result.err != nil {
+		return result
+	}
+	if result.success || (result.statusCode >= 400 && result.statusCode < 500) {
+		c.breaker.RecordSuccess()
+	} else {
+		atomic.StoreInt32(&c.lastFailedStatus, int32(result.statusCode))
+		c.breaker.RecordFailure()
+	}
+	return result
+}
+
+// sendAttempts calls the API for the message until the retry policy stops,
+// or ctx is done
+func (c *Consumer) sendAttempts(ctx context.Context, msg *validator.WeatherMessage, headers map[string]string) sendResult {
+	var result sendResult
+	maxAttempts, _ := c.RetrySettings()
+	policy := c.currentRetryPolicy()
+	for attempt := 1; ; attempt++ {
+		attemptID := api_client.NewRequestID()
 		c.logger.Debug("Sending to API", map[string]interface{}{
 			"attempt":     attempt,
-			"max_retries": c.config.RetryAttempts,
+			"max_retries": maxAttempts,
+			"request_id":  attemptID,
 		})
 
-		resp := c.apiClient.SendWeatherData(msg)
+		started := time.Now()
+		resp := c.apiClient.SendWeatherDataCtx(ctx, msg, attemptHeaders(headers, attemptID))
+		c.observeAPIRequest(time.Since(started), resp.StatusCode, attempt)
+		result.attempts = attempt
+		result.statusCode = resp.StatusCode
+		result.requestID = resp.RequestID()
+		result.attemptID = attemptID
+		c.logger.Debug("API attempt finished", withRequestID(map[string]interface{}{
+			"attempt":     attempt,
+			"request_id":  attemptID,
+			"status_code": resp.StatusCode,
+		}, result.requestID))
+		if err := ctx.Err(); err != nil {
+			result.err = err
+			return result
+		}
 
-		if resp.IsSuccess() {
-			return true
+		class := c.statuses.Classify(resp)
+		if class == api_client.StatusSuccess {
+			result.success = true
+			result.resourceID, _ = resp.ResourceID()
+			return result
 		}
 
-		if resp.IsClientError() {
-			// Don't retry on client errors (4xx)
-			c.logger.Error("Client error from API", map[string]interface{}{
+		retry, delay := policy.ShouldRetry(resp, attempt)
+		switch {
+		case class == api_client.StatusTerminal && !retry:
+			c.logger.Error("Client error from API", withRequestID(map[string]interface{}{
 				"status_code": resp.StatusCode,
 				"body":        string(resp.Body),
-			})
-			return false
-		}
-
-		if resp.Error != nil {
+				"request_id":  attemptID,
+			}, result.requestID))
+			result.rejected = true
+			return result
+		case resp.Error != nil:
 			c.logger.Warn("API request failed", map[string]interface{}{
-				"error":   resp.Error.Error(),
-				"attempt": attempt,
+				"error":       resp.Error.Error(),
+				"error_chain": logger.ErrorChain(resp.Error),
+				"attempt":     attempt,
+				"request_id":  attemptID,
 			})
-		} else {
-			c.logger.Warn("API returned error status", map[string]interface{}{
+		default:
+			c.logger.Warn("API returned error status", withRequestID(map[string]interface{}{
 				"status_code": resp.StatusCode,
 				"attempt":     attempt,
-			})
+				"request_id":  attemptID,
+			}, result.requestID))
 		}
 
-		if attempt < c.config.RetryAttempts {
-			time.Sleep(c.config.RetryDelay)
+		if !retry {
+			return result
+		}
+		if !c.retryBudget.allow() {
+			c.metrics.retryBudgetExhausted.Inc()
+			c.logger.Warn("Retry budget exhausted, failing fast", withRequestID(map[string]interface{}{
+				"status_code":       resp.StatusCode,
+				"attempt":           attempt,
+				"budget_per_second": c.config.RetryBudgetPerSecond,
+			}, result.requestID))
+			return result
+		}
+		select {
+		case <-ctx.Done():
+			result.err = ctx.Err()
+			return result
+		case <-time.After(delay):
 		}
 	}
+}
 
-	return false
+// attemptHeaders returns the message's forwarded headers plus the attempt's
+// X-Request-Id, leaving the message's map untouched across retries
+func attemptHeaders(headers map[string]string, attemptID string) map[string]string {
+	if attemptID == "" {
+		return headers
+	}
+
+	withID := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		withID[key] = value
+	}
+	withID[api_client.RequestIDHeader] = attemptID
+	return withID
+}
+
+// withRequestID adds the API's request id to a log context when there is one
+func withRequestID(context map[string]interface{}, requestID string) map[string]interface{} {
+	if requestID != "" {
+		context["api_request_id"] = requestID
+	}
+	return context
 }
 
 // Close closes the connection and channel
 func (c *Consumer) Close() {
+	atomic.StoreInt32(&c.shuttingDown, 1)
+	c.setConnected(false)
 	if c.channel != nil {
 		c.channel.Close()
 	}
@@ -206,14 +706,21 @@ func (c *Consumer) Close() {
 
 // ProcessSingleMessage processes a single message (for testing)
 func (c *Consumer) ProcessSingleMessage(body []byte) (validated bool, apiSuccess bool) {
-	msg, err := validator.ValidateMessage(body)
-	if err != nil {
-		c.logger.Error("Message validation failed", map[string]interface{}{
-			"error": err.Error(),
-		})
-		return false, false
+	validated, apiSuccess, _ = c.ProcessSingleMessageCtx(context.Background(), body)
+	return validated, apiSuccess
+}
+
+// ProcessSingleMessageCtx processes a single message like
+// ProcessSingleMessage, returning ctx.Err() if ctx is done before the API
+// accepts or rejects the message
+func (c *Consumer) ProcessSingleMessageCtx(ctx context.Context, body []byte) (validated bool, apiSuccess bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return false, false, err
 	}
 
-	success := c.sendWithRetry(msg)
-	return true, success
+	result := c.chain(ctx, &Message{Body: body})
+	if result.ValidationErr != nil {
+		return false, false, nil
+	}
+	return true, result.Success, result.Err
 }
@@ -0,0 +1,58 @@
+//go:build testgen
+
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/prop"
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/config"
+	"queue-worker/internal/logger"
+	"queue-worker/internal/testgen"
+	"queue-worker/internal/validator"
+)
+
+// Property tests over messages generated by internal/testgen
+
+func TestPropertyGen_OnlyValidMessagesReachAPI(t *testing.T) {
+	properties := gopter.NewProperties(gopter.DefaultTestParameters())
+
+	// apiCalledFor processes body against a test API and reports whether it
+	// was validated and whether the API was called
+	apiCalledFor := func(body []byte) (validated, called bool) {
+		var apiCalled atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiCalled.Store(true)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		cfg := &config.Config{APIServiceURL: server.URL, RetryAttempts: 1}
+		cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+		validated, _ = cons.ProcessSingleMessage(body)
+		return validated, apiCalled.Load()
+	}
+
+	properties.Property("generated valid messages trigger API calls", prop.ForAll(
+		func(msg *validator.WeatherMessage) bool {
+			validated, called := apiCalledFor(testgen.JSON(msg))
+			return validated && called
+		},
+		testgen.ValidMessage(),
+	))
+
+	properties.Property("out-of-range messages don't trigger API calls", prop.ForAll(
+		func(invalid testgen.Invalid) bool {
+			validated, called := apiCalledFor(testgen.JSON(invalid.Message))
+			return !validated && !called
+		},
+		testgen.InvalidMessage(),
+	))
+
+	properties.TestingRun(t)
+}
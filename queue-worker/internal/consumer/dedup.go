@@ -0,0 +1,24 @@
+package consumer
+
+import (
+	"queue-worker/internal/dedup"
+)
+
+// checkDuplicate hashes a message body and reports whether the dedup store
+// has already seen it processed. The hash is empty when dedup is disabled.
+func (c *Consumer) checkDuplicate(body []byte) (hash string, duplicate bool) {
+	if c.dedup == nil {
+		return "", false
+	}
+	hash = dedup.Hash(body)
+	return hash, c.dedup.Seen(hash)
+}
+
+// rememberProcessed records a message accepted by the API, so redeliveries
+// and producer double-publishes of it are skipped
+func (c *Consumer) rememberProcessed(hash string) {
+	if c.dedup == nil || hash == "" {
+		return
+	}
+	c.dedup.Add(hash)
+}
@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/dedup"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for skipping duplicate messages
+
+func TestProcessMessage_SkipsDuplicateOfProcessedMessage(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	log := logger.New("test")
+	cfg := createTestConfig(server.URL)
+	cfg.DedupCacheSize = 10
+	cons := New(cfg, api_client.NewClient(server.URL), log)
+	ack := &fakeAcknowledger{}
+	body := createValidMessageJSON()
+
+	cons.processMessage(createDelivery(ack, 1, body))
+	cons.processMessage(createDelivery(ack, 2, body))
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected 1 API request, got %d", got)
+	}
+	if len(ack.acked) != 2 {
+		t.Errorf("Expected both deliveries acked, got %v", ack.acked)
+	}
+	if got := cons.metrics.duplicatesSkipped.Value(); got != 1 {
+		t.Errorf("Expected 1 duplicate skipped, got %v", got)
+	}
+	entry, ok := findLog(log, "Duplicate message skipped")
+	if !ok || entry.Level != logger.DEBUG {
+		t.Fatalf("Expected debug duplicate log, got %+v", entry)
+	}
+	if entry.Context["content_hash"] != dedup.Hash(body) {
+		t.Errorf("Expected content hash %s, got %v", dedup.Hash(body), entry.Context["content_hash"])
+	}
+}
+
+func TestProcessMessage_DoesNotRememberFailedMessages(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.DedupCacheSize = 10
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	body := createValidMessageJSON()
+
+	cons.ProcessSingleMessage(body)
+	cons.ProcessSingleMessage(body)
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected failed message to be sent again, got %d requests", got)
+	}
+	if got := cons.metrics.duplicatesSkipped.Value(); got != 0 {
+		t.Errorf("Expected no duplicates skipped, got %v", got)
+	}
+}
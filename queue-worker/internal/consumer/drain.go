@@ -0,0 +1,88 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// drainChecks is how many times per DrainIdlePeriod drain mode checks the
+// queue, so an empty queue must be seen repeatedly before the worker stops
+const drainChecks = 5
+
+// errNoChannel reports that the queue can't be inspected while disconnected
+var errNoChannel = errors.New("no open channel")
+
+// watchDrain stops the consumer once the queue has been empty, with nothing
+// in flight or spooled, for DrainIdlePeriod. Start then returns nil once the
+// deliveries already received are processed. Any backlog, or a failure to
+// inspect the queue, restarts the idle period.
+func (c *Consumer) watchDrain(ctx context.Context) {
+	ticker := time.NewTicker(c.config.DrainIdlePeriod / drainChecks)
+	defer ticker.Stop()
+
+	var emptySince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		if !c.drained() {
+			emptySince = time.Time{}
+			continue
+		}
+		if emptySince.IsZero() {
+			emptySince = time.Now()
+			continue
+		}
+		if idle := time.Since(emptySince); idle >= c.config.DrainIdlePeriod {
+			c.logger.Info("Queue drained, stopping consumer", map[string]interface{}{
+				"queue":   c.config.QueueName,
+				"idle_ms": idle.Milliseconds(),
+			})
+			c.stop()
+			return
+		}
+	}
+}
+
+// drained reports whether there is no work left for this worker: no message
+// in the queue, being processed, waiting in the spool, or deferred or held
+// unsettled for a later requeue
+func (c *Consumer) drained() bool {
+	if atomic.LoadInt64(&c.inFlight) > 0 {
+		return false
+	}
+	if atomic.LoadInt64(&c.deferred) > 0 || atomic.LoadInt32(&c.holding) > 0 {
+		return false
+	}
+	if c.spool != nil && c.spool.Size() > 0 {
+		return false
+	}
+
+	depth, err := c.queueDepth()
+	if err != nil {
+		c.logger.Warn("Failed to inspect queue", map[string]interface{}{
+			"queue": c.config.QueueName,
+			"error": err.Error(),
+		})
+		return false
+	}
+	return depth == 0
+}
+
+// inspectQueue returns the number of messages ready in the queue, declaring
+// it passively so a missing queue isn't created. It backs /stats and drain
+// mode, so it uses declarePassive's throwaway channel.
+func (c *Consumer) inspectQueue() (int, error) {
+	queue, err := c.declarePassive()
+	if err != nil {
+		return 0, err
+	}
+	return queue.Messages, nil
+}
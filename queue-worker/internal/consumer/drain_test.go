@@ -0,0 +1,113 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/breaker"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for drain-and-exit mode
+
+func newDrainingConsumer(log *logger.Logger, depth func() (int, error)) *Consumer {
+	cfg := createTestConfig("http://example.com")
+	cfg.DrainAndExit = true
+	cfg.DrainIdlePeriod = 50 * time.Millisecond
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+	cons.queueDepth = depth
+	return cons
+}
+
+// runDrain runs watchDrain for at most d and reports whether it stopped the consumer
+func runDrain(cons *Consumer, d time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	cons.watchDrain(ctx)
+	return cons.stopping()
+}
+
+func TestWatchDrain_StopsWhenQueueStaysEmpty(t *testing.T) {
+	log := logger.New("test")
+	cons := newDrainingConsumer(log, func() (int, error) { return 0, nil })
+
+	started := time.Now()
+	if !runDrain(cons, time.Second) {
+		t.Fatal("Expected an empty queue to stop the consumer")
+	}
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the queue to stay empty for the idle period, stopped after %v", elapsed)
+	}
+	if _, ok := findLog(log, "Queue drained, stopping consumer"); !ok {
+		t.Error("Expected drained log")
+	}
+}
+
+func TestWatchDrain_KeepsRunningWithBacklog(t *testing.T) {
+	cons := newDrainingConsumer(logger.New("test"), func() (int, error) { return 3, nil })
+
+	if runDrain(cons, 200*time.Millisecond) {
+		t.Error("Expected a queue with messages not to stop the consumer")
+	}
+}
+
+func TestWatchDrain_WaitsForInFlightMessages(t *testing.T) {
+	cons := newDrainingConsumer(logger.New("test"), func() (int, error) { return 0, nil })
+	atomic.StoreInt64(&cons.inFlight, 1)
+
+	if runDrain(cons, 200*time.Millisecond) {
+		t.Error("Expected an in-flight message to keep the consumer running")
+	}
+}
+
+func TestWatchDrain_WaitsForHeldMessages(t *testing.T) {
+	cons := newDrainingConsumer(logger.New("test"), func() (int, error) { return 0, nil })
+	cons.breaker = breaker.New(1, time.Minute)
+	cons.breaker.RecordFailure()
+
+	ack := &fakeAcknowledger{}
+	cons.holdAndRequeue(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if runDrain(cons, 200*time.Millisecond) {
+		t.Error("Expected a held message to keep the consumer running")
+	}
+	if len(ack.nacked) != 0 {
+		t.Errorf("Expected the message to still be held, got nacks %v", ack.nacked)
+	}
+	cons.stop()
+}
+
+func TestWatchDrain_BacklogRestartsIdlePeriod(t *testing.T) {
+	var checks atomic.Int32
+	cons := newDrainingConsumer(logger.New("test"), func() (int, error) {
+		// Empty, then a message arrives just before the idle period ends
+		if checks.Add(1) == 5 {
+			return 1, nil
+		}
+		return 0, nil
+	})
+
+	started := time.Now()
+	if !runDrain(cons, time.Second) {
+		t.Fatal("Expected the consumer to stop once the queue stays empty")
+	}
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the backlog to restart the idle period, stopped after %v", elapsed)
+	}
+}
+
+func TestWatchDrain_InspectErrorIsNotEmpty(t *testing.T) {
+	log := logger.New("test")
+	cons := newDrainingConsumer(log, func() (int, error) { return 0, errors.New("channel closed") })
+
+	if runDrain(cons, 200*time.Millisecond) {
+		t.Error("Expected a failed inspection not to count as an empty queue")
+	}
+	if _, ok := findLog(log, "Failed to inspect queue"); !ok {
+		t.Error("Expected inspection failure to be logged")
+	}
+}
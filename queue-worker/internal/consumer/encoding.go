@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// defaultMaxDecodedBytes caps decompressed bodies when MaxMessageBytes is
+// unset, so a small gzip bomb can't exhaust memory
+const defaultMaxDecodedBytes = 16 << 20
+
+// errDecodedTooLarge reports a body that decompresses past the size limit
+var errDecodedTooLarge = errors.New("decoded body exceeds size limit")
+
+// unsupportedEncodingError reports a content encoding the worker can't decode
+type unsupportedEncodingError struct {
+	encoding string
+}
+
+func (e unsupportedEncodingError) Error() string {
+	return fmt.Sprintf("unsupported content encoding %q", e.encoding)
+}
+
+// contentEncoding returns the declared encoding of a delivery: the AMQP
+// content-encoding property, or a content-encoding header for producers that
+// set it as a header instead
+func contentEncoding(delivery amqp.Delivery) string {
+	if delivery.ContentEncoding != "" {
+		return strings.ToLower(delivery.ContentEncoding)
+	}
+	for key, value := range delivery.Headers {
+		if s, ok := value.(string); ok && strings.EqualFold(key, "content-encoding") {
+			return strings.ToLower(s)
+		}
+	}
+	return ""
+}
+
+// withoutContentEncoding returns a copy of headers without the
+// content-encoding header contentEncoding reads, for a body taken from the
+// decoded delivery
+func withoutContentEncoding(headers amqp.Table) amqp.Table {
+	copied := copyTable(headers)
+	for key := range copied {
+		if strings.EqualFold(key, "content-encoding") {
+			delete(copied, key)
+		}
+	}
+	return copied
+}
+
+// decodeBody returns the delivery body with its content encoding removed.
+// Bodies without a declared encoding are sniffed for the gzip magic bytes.
+// Decompressing stops with errDecodedTooLarge past limit bytes.
+func decodeBody(delivery amqp.Delivery, limit int) ([]byte, error) {
+	encoding := contentEncoding(delivery)
+	if encoding == "" && bytes.HasPrefix(delivery.Body, gzipMagic) {
+		encoding = "gzip"
+	}
+
+	switch encoding {
+	case "", "identity":
+		return delivery.Body, nil
+	case "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(delivery.Body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer reader.Close()
+
+		body, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		if len(body) > limit {
+			return nil, errDecodedTooLarge
+		}
+		return body, nil
+	default:
+		return nil, unsupportedEncodingError{encoding: encoding}
+	}
+}
@@ -0,0 +1,28 @@
+package consumer
+
+import (
+	"queue-worker/internal/enrich"
+	"queue-worker/internal/validator"
+)
+
+// SetRegionLookup sets how the region sent to the API is derived from a
+// message's coordinates (enrich.NoopLookup by default)
+func (c *Consumer) SetRegionLookup(lookup enrich.RegionLookup) {
+	c.regions = lookup
+}
+
+// enrichRegion fills msg.Location.Region from its coordinates. A failed
+// lookup is logged and the message is sent without a region.
+func (c *Consumer) enrichRegion(deliveryTag uint64, msg *validator.WeatherMessage) {
+	region, err := c.regions.Region(msg.Location.Latitude, msg.Location.Longitude)
+	if err != nil {
+		c.logger.Warn("Failed to look up region", map[string]interface{}{
+			"delivery_tag": deliveryTag,
+			"error":        err.Error(),
+		})
+		return
+	}
+	if region != "" {
+		msg.Location.Region = region
+	}
+}
@@ -0,0 +1,30 @@
+package consumer
+
+import (
+	"time"
+
+	"queue-worker/internal/validator"
+)
+
+// messageAge returns how old a message is, measured from its body timestamp,
+// or from the AMQP timestamp property when the body has none. ok is false
+// when neither is available.
+func messageAge(msg *Message, parsed *validator.WeatherMessage, now time.Time) (age time.Duration, ok bool) {
+	if t, err := time.Parse(time.RFC3339Nano, parsed.Timestamp); err == nil {
+		return now.Sub(t), true
+	}
+	if !msg.Timestamp.IsZero() {
+		return now.Sub(msg.Timestamp), true
+	}
+	return 0, false
+}
+
+// expired reports whether a message is older than MaxMessageAge. Messages
+// whose age is unknown are never expired.
+func (c *Consumer) expired(msg *Message, parsed *validator.WeatherMessage) (time.Duration, bool) {
+	if c.config.MaxMessageAge <= 0 {
+		return 0, false
+	}
+	age, ok := messageAge(msg, parsed, time.Now())
+	return age, ok && age > c.config.MaxMessageAge
+}
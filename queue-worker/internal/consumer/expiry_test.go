@@ -0,0 +1,106 @@
+package consumer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+	"queue-worker/internal/validator"
+)
+
+// Unit tests for dropping messages older than MaxMessageAge
+
+// createMessageAt returns a valid message body with the given timestamp,
+// omitting it when timestamp is empty
+func createMessageAt(timestamp string) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createValidMessageJSON(), &msg)
+	if timestamp == "" {
+		delete(msg, "timestamp")
+	} else {
+		msg["timestamp"] = timestamp
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func newExpiryConsumer(t *testing.T, requests *int32) (*Consumer, *fakePublisher) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createTestConfig(server.URL)
+	cfg.MaxMessageAge = time.Hour
+	cfg.DeadLetterQueue = "weather-dlq"
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	return cons, pub
+}
+
+func TestProcessMessage_FreshMessageSent(t *testing.T) {
+	var requests int32
+	cons, pub := newExpiryConsumer(t, &requests)
+	ack := &fakeAcknowledger{}
+
+	fresh := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	cons.processMessage(createDelivery(ack, 1, createMessageAt(fresh)))
+
+	if atomic.LoadInt32(&requests) != 1 || len(ack.acked) != 1 {
+		t.Errorf("Expected fresh message sent and acked, got %d requests, acked=%v", requests, ack.acked)
+	}
+	if len(pub.published) != 0 {
+		t.Errorf("Expected nothing dead-lettered, got %+v", pub.published)
+	}
+}
+
+func TestProcessMessage_ExpiredMessageDeadLettered(t *testing.T) {
+	var requests int32
+	cons, pub := newExpiryConsumer(t, &requests)
+
+	stale := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 1, createMessageAt(stale)))
+
+	if atomic.LoadInt32(&requests) != 0 {
+		t.Errorf("Expected expired message not to be sent, got %d requests", requests)
+	}
+	if len(pub.published) != 1 || pub.published[0].msg.Headers[DLQReasonHeader] != DLQReasonExpired {
+		t.Fatalf("Expected message dead-lettered as expired, got %+v", pub.published)
+	}
+	if got := cons.metrics.expired.Value("other"); got != 1 {
+		t.Errorf("Expected expired message counted, got %v", got)
+	}
+}
+
+func TestProcessMessage_AgeFromAMQPTimestamp(t *testing.T) {
+	var requests int32
+	cons, pub := newExpiryConsumer(t, &requests)
+	cons.validator = validatorWithoutTimestamp()
+
+	delivery := createDelivery(&fakeAcknowledger{}, 1, createMessageAt(""))
+	delivery.Timestamp = time.Now().Add(-2 * time.Hour)
+	cons.processMessage(delivery)
+
+	if len(pub.published) != 1 || pub.published[0].msg.Headers[DLQReasonHeader] != DLQReasonExpired {
+		t.Errorf("Expected message expired by its AMQP timestamp, got %+v", pub.published)
+	}
+
+	// Without either timestamp the age is unknown, so the message is sent
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 2, createMessageAt("")))
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected message without timestamps to be sent, got %d requests", requests)
+	}
+}
+
+// validatorWithoutTimestamp accepts messages that omit the body timestamp
+func validatorWithoutTimestamp() *validator.Validator {
+	return validator.New(validator.Options{RequiredFields: []string{"location.city", "weather.condition", "source"}})
+}
@@ -0,0 +1,78 @@
+package consumer
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"queue-worker/internal/validator"
+)
+
+// maxFingerprints bounds the distinct message shapes remembered, so a
+// producer whose keys vary with the data can't grow memory without bound
+const maxFingerprints = 1000
+
+// shapeTracker samples raw message bodies and remembers the distinct schema
+// fingerprints seen, to reveal producers adding or dropping fields
+type shapeTracker struct {
+	every  uint64 // fingerprint 1 in every messages
+	counts uint64 // messages seen, for sampling
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newShapeTracker(every int) *shapeTracker {
+	return &shapeTracker{every: uint64(every), seen: make(map[string]bool)}
+}
+
+// sample reports whether the next message should be fingerprinted
+func (s *shapeTracker) sample() bool {
+	return (atomic.AddUint64(&s.counts, 1)-1)%s.every == 0
+}
+
+// add records a fingerprint, reporting whether it is new. Once
+// maxFingerprints are remembered, unseen fingerprints are still reported as
+// new but no longer remembered.
+func (s *shapeTracker) add(fingerprint string) (isNew bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[fingerprint] {
+		return false
+	}
+	if len(s.seen) < maxFingerprints {
+		s.seen[fingerprint] = true
+	}
+	return true
+}
+
+// distinct returns how many fingerprints have been seen, up to maxFingerprints
+func (s *shapeTracker) distinct() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
+
+// observeShape fingerprints a sample of raw bodies and logs the fingerprint
+// at Debug, with the field paths the first time a shape is seen. Bodies
+// that aren't JSON objects are left to validation to report.
+func (c *Consumer) observeShape(deliveryTag uint64, body []byte) {
+	if c.shapes == nil || !c.shapes.sample() {
+		return
+	}
+	fingerprint, paths, err := validator.Fingerprint(body)
+	if err != nil {
+		return
+	}
+
+	context := map[string]interface{}{
+		"delivery_tag": deliveryTag,
+		"fingerprint":  fingerprint,
+		"field_count":  len(paths),
+	}
+	if c.shapes.add(fingerprint) {
+		context["new"] = true
+		context["fields"] = paths
+		c.metrics.schemaFingerprints.Set(float64(c.shapes.distinct()))
+	}
+	c.logger.Debug("Message schema fingerprint", context)
+}
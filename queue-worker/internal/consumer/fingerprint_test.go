@@ -0,0 +1,68 @@
+package consumer
+
+import (
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for sampled message schema fingerprints
+
+func TestObserveShape_LogsNewShapesAndCountsDistinct(t *testing.T) {
+	log := logger.New("test")
+	cfg := createTestConfig("http://example.com")
+	cfg.SchemaFingerprintSample = 1
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+
+	cons.observeShape(1, []byte(`{"source":"a","location":{"city":"Recife"}}`))
+	entry, ok := findLog(log, "Message schema fingerprint")
+	if !ok || entry.Level != logger.DEBUG || entry.Context["new"] != true || entry.Context["fingerprint"] == "" {
+		t.Fatalf("Expected a debug log for the new shape, got %+v", entry)
+	}
+
+	cons.observeShape(2, []byte(`{"source":"b","location":{"city":"Natal"}}`))
+	if entry, _ := findLog(log, "Message schema fingerprint"); entry.Context["new"] != nil {
+		t.Errorf("Expected a known shape not to be marked new, got %+v", entry.Context)
+	}
+
+	cons.observeShape(3, []byte(`{"source":"b","location":{"city":"Natal","state":"RN"}}`))
+	if got := cons.Stats().SchemaFingerprints; got != 2 {
+		t.Errorf("Expected 2 distinct fingerprints, got %d", got)
+	}
+	if got := cons.metrics.schemaFingerprints.Value(); got != 2 {
+		t.Errorf("Expected gauge 2, got %v", got)
+	}
+}
+
+func TestObserveShape_Samples(t *testing.T) {
+	log := logger.New("test")
+	cfg := createTestConfig("http://example.com")
+	cfg.SchemaFingerprintSample = 3
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+
+	for i := 0; i < 6; i++ {
+		cons.observeShape(uint64(i), createValidMessageJSON())
+	}
+
+	count := 0
+	for _, entry := range log.GetEntries() {
+		if entry.Message == "Message schema fingerprint" {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 of 6 messages fingerprinted, got %d", count)
+	}
+}
+
+func TestShapeTracker_BoundsRememberedFingerprints(t *testing.T) {
+	shapes := newShapeTracker(1)
+	for i := 0; i < maxFingerprints+10; i++ {
+		shapes.add(string(rune(i)))
+	}
+
+	if got := shapes.distinct(); got != maxFingerprints {
+		t.Errorf("Expected %d fingerprints remembered, got %d", maxFingerprints, got)
+	}
+}
@@ -0,0 +1,36 @@
+package consumer
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// errNoMessageYet is reported by CheckReady until the first delivery has been
+// processed, when Config.ReadyAfterFirstMessage is set
+var errNoMessageYet = errors.New("no message processed yet")
+
+// markFirstMessage records when the first delivery finished processing,
+// logging and counting it once however many workers finish at the same time
+func (c *Consumer) markFirstMessage(o *messageOutcome) {
+	if atomic.LoadInt64(&c.firstMessage) != 0 {
+		return
+	}
+	now := time.Now()
+	if !atomic.CompareAndSwapInt64(&c.firstMessage, 0, now.UnixNano()) {
+		return
+	}
+
+	c.metrics.firstMessage.Set(1)
+	c.logger.Info("First message processed", map[string]interface{}{
+		"delivery_tag":   o.deliveryTag,
+		"outcome":        o.outcome,
+		"since_start_ms": now.Sub(c.started).Milliseconds(),
+	})
+}
+
+// FirstMessageProcessed reports whether any delivery has finished processing,
+// whatever its outcome
+func (c *Consumer) FirstMessageProcessed() bool {
+	return atomic.LoadInt64(&c.firstMessage) != 0
+}
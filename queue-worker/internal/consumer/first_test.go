@@ -0,0 +1,72 @@
+package consumer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the first-message readiness marker
+
+func TestProcessMessage_MarksFirstMessageOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	log := logger.New("test")
+	cons := New(createTestConfig(server.URL), api_client.NewClient(server.URL), log)
+	if cons.FirstMessageProcessed() || cons.Stats().FirstMessage != nil {
+		t.Fatal("Expected no first message before processing")
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(tag uint64) {
+			defer wg.Done()
+			cons.processMessage(createDelivery(&fakeAcknowledger{}, tag, createValidMessageJSON()))
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	if !cons.FirstMessageProcessed() || cons.Stats().FirstMessage == nil {
+		t.Error("Expected the first message to be recorded")
+	}
+	if got := cons.metrics.firstMessage.Value(); got != 1 {
+		t.Errorf("Expected first message gauge 1, got %v", got)
+	}
+	count := 0
+	for _, entry := range log.GetEntries() {
+		if entry.Message == "First message processed" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected the first message logged once, got %d", count)
+	}
+}
+
+func TestCheckReady_WaitsForFirstMessage(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.ReadyAfterFirstMessage = true
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+	cons.queueProbe = func() error { return nil }
+	cons.setConnected(true)
+	atomic.StoreInt32(&cons.subscribed, 1)
+
+	if err := cons.CheckReady(); !errors.Is(err, errNoMessageYet) {
+		t.Errorf("Expected not ready before the first message, got %v", err)
+	}
+
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 1, []byte("invalid")))
+	if err := cons.CheckReady(); err != nil {
+		t.Errorf("Expected ready after the first message, got %v", err)
+	}
+}
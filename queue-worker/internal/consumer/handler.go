@@ -0,0 +1,220 @@
+package consumer
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"queue-worker/internal/validator"
+)
+
+// Message is a decoded delivery passing through the handler chain
+type Message struct {
+	DeliveryTag uint64
+	Body        []byte
+	Headers     map[string]string // delivery headers forwarded to the API
+	Timestamp   time.Time         // AMQP timestamp property, zero if unset
+	Priority    string            // from the PriorityHeader delivery header, empty if unset
+	Publisher   string            // publisher id from the delivery, see Config.PublisherHeader
+}
+
+// Result is what handling a message produced. Settling the delivery (ack,
+// nack, dead-lettering) stays with the consumer, which decides from Result.
+type Result struct {
+	Parsed        *validator.WeatherMessage // nil if the body failed validation or was a duplicate
+	ValidationErr error
+	Duplicate     bool          // already processed according to the dedup store, so skipped
+	ContentHash   string        // hash of the body, set when dedup is enabled
+	Expired       bool          // older than MaxMessageAge, so not sent
+	Age           time.Duration // set when Expired
+	Spooled       bool          // written to the spool instead of sent
+	Success       bool          // accepted by the API
+	SinkFailed    bool          // accepted, but the SinkRequired sink publish failed
+	SinkRetry     bool          // accepted earlier, so only the sink publish was retried
+	Rejected      bool          // refused by the API with a client error that isn't retried
+	Attempts      int
+	StatusCode    int    // status of the last API response, 0 if none
+	ResourceID    string // id the API assigned to the record, if any
+	RequestID     string // id the API assigned to the last request, if any
+	AttemptID     string // X-Request-Id the worker sent with the last request
+	Err           error  // ctx.Err() if the send was cancelled
+}
+
+// Handler processes one message
+type Handler func(ctx context.Context, msg *Message) *Result
+
+// Middleware wraps a Handler with cross-cutting behavior, such as tracing,
+// deduplication or enrichment
+type Middleware func(next Handler) Handler
+
+// Use adds middleware around the consumer's handler chain. Middleware runs
+// in the order given and before the built-in logging and metrics middleware.
+func (c *Consumer) Use(mw ...Middleware) {
+	c.middleware = append(append([]Middleware(nil), mw...), c.middleware...)
+	c.buildChain()
+}
+
+// buildChain composes the middleware around the core validate+send handler
+func (c *Consumer) buildChain() {
+	handler := c.handle
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](handler)
+	}
+	c.chain = handler
+}
+
+// handle is the core step: skip the body if the dedup store has seen it,
+// transform and validate it, skip it if it has expired, then spool it while
+// the API circuit is open or send it to the API
+func (c *Consumer) handle(ctx context.Context, msg *Message) *Result {
+	if c.sinkPendingFor(msg.Body) {
+		return c.retrySink(msg)
+	}
+	hash, duplicate := c.checkDuplicate(msg.Body)
+	if duplicate {
+		// The API already accepted this body
+		return &Result{Duplicate: true, Success: true, ContentHash: hash}
+	}
+	c.observeShape(msg.DeliveryTag, msg.Body)
+
+	body, err := c.transformBody(msg.Body)
+	if err != nil {
+		return &Result{ValidationErr: err}
+	}
+	parsed, err := c.validator.ValidateMessage(body)
+	if err == nil {
+		err = c.validator.CheckPublisher(parsed, msg.Publisher)
+	}
+	if err != nil {
+		return &Result{ValidationErr: err}
+	}
+	if age, expired := c.expired(msg, parsed); expired {
+		return &Result{Parsed: parsed, Expired: true, Age: age}
+	}
+
+	c.checkOrdering(msg.DeliveryTag, parsed)
+	c.enrichRegion(msg.DeliveryTag, parsed)
+
+	result := &Result{Parsed: parsed}
+	if c.trySpool(msg.DeliveryTag, parsed) {
+		result.Spooled = true
+		return result
+	}
+
+	sent := c.sendWithRetry(c.withPriorityTimeout(ctx, msg.Priority), parsed, msg.Headers)
+	result.Success = sent.success
+	result.Rejected = sent.rejected
+	result.Attempts = sent.attempts
+	result.StatusCode = sent.statusCode
+	result.ResourceID = sent.resourceID
+	result.RequestID = sent.requestID
+	result.AttemptID = sent.attemptID
+	result.Err = sent.err
+	result.ContentHash = hash
+	if result.Success {
+		c.rememberProcessed(hash)
+		result.SinkFailed = !c.emitToSink(msg, parsed)
+	}
+	return result
+}
+
+// loggingMiddleware logs how each message was handled
+func (c *Consumer) loggingMiddleware(next Handler) Handler {
+	return func(ctx context.Context, msg *Message) *Result {
+		result := next(ctx, msg)
+
+		switch {
+		case result.Duplicate:
+			c.logger.Debug("Duplicate message skipped", map[string]interface{}{
+				"delivery_tag": msg.DeliveryTag,
+				"content_hash": result.ContentHash,
+			})
+		case result.ValidationErr != nil:
+			var context map[string]interface{}
+			if msg.DeliveryTag != 0 {
+				context = map[string]interface{}{"delivery_tag": msg.DeliveryTag}
+			}
+			c.rejectMessage(msg.Body, result.ValidationErr, context)
+		case result.Expired:
+			c.logger.Warn("Message expired", map[string]interface{}{
+				"delivery_tag":   msg.DeliveryTag,
+				"age_ms":         result.Age.Milliseconds(),
+				"max_message_ms": c.config.MaxMessageAge.Milliseconds(),
+				"source":         result.Parsed.Source,
+			})
+		case result.Spooled:
+		case result.SinkFailed:
+			c.logger.Warn("Message accepted by API but not published to required sink, requeueing", withRequestID(map[string]interface{}{
+				"delivery_tag": msg.DeliveryTag,
+				"source":       result.Parsed.Source,
+				"retried":      result.SinkRetry,
+			}, result.RequestID))
+		case result.SinkRetry:
+			c.logger.Info("Message published to secondary sink on retry", map[string]interface{}{
+				"delivery_tag": msg.DeliveryTag,
+				"source":       result.Parsed.Source,
+			})
+		case result.Err != nil:
+			c.logger.Warn("Message processing cancelled", map[string]interface{}{
+				"error":    result.Err.Error(),
+				"attempts": result.Attempts,
+			})
+		case result.Success:
+			context := map[string]interface{}{
+				"delivery_tag":   msg.DeliveryTag,
+				"timestamp":      result.Parsed.Timestamp,
+				"city":           result.Parsed.Location.City,
+				"source":         result.Parsed.Source,
+				"schema_version": result.Parsed.Version(),
+			}
+			if result.ResourceID != "" {
+				context["resource_id"] = result.ResourceID
+			}
+			c.logger.Info("Message processed successfully", withRequestID(context, result.RequestID))
+		default:
+			c.logger.Error("Failed to send message to API after retries", withRequestID(map[string]interface{}{
+				"delivery_tag": msg.DeliveryTag,
+				"source":       result.Parsed.Source,
+				"request_id":   result.AttemptID,
+			}, result.RequestID))
+		}
+		return result
+	}
+}
+
+// metricsMiddleware records skipped duplicates, validation failures and, for messages sent to
+// the API, the processing time and whether the API accepted them
+func (c *Consumer) metricsMiddleware(next Handler) Handler {
+	return func(ctx context.Context, msg *Message) *Result {
+		started := time.Now()
+		result := next(ctx, msg)
+
+		if result.Duplicate {
+			c.metrics.duplicatesSkipped.Inc()
+			return result
+		}
+		if result.ValidationErr != nil {
+			c.recordValidationFailure(result.ValidationErr)
+			return result
+		}
+		if result.Expired {
+			c.metrics.expired.Inc(c.sourceLabel(result.Parsed.Source))
+			return result
+		}
+		if result.Spooled || result.SinkRetry || result.Err != nil {
+			// SinkRetry: the API call was counted when it was made
+			return result
+		}
+
+		source := c.sourceLabel(result.Parsed.Source)
+		c.metrics.duration.Observe(time.Since(started).Seconds(), source)
+		if result.Success {
+			c.metrics.processed.Inc(source)
+			atomic.StoreInt64(&c.lastSuccess, time.Now().UnixNano())
+		} else {
+			c.metrics.failed.Inc(source)
+			atomic.StoreInt64(&c.lastFailure, time.Now().UnixNano())
+		}
+		return result
+	}
+}
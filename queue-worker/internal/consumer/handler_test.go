@@ -0,0 +1,158 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+	"queue-worker/internal/validator"
+)
+
+// Unit tests for the message handler chain
+
+var errDuplicate = errors.New("duplicate message")
+
+func TestUse_RunsMiddlewareInOrderAroundCore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cons := New(createTestConfig(server.URL), api_client.NewClient(server.URL), logger.New("test"))
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, msg *Message) *Result {
+				calls = append(calls, name+" before")
+				result := next(ctx, msg)
+				calls = append(calls, name+" after")
+				return result
+			}
+		}
+	}
+	cons.Use(record("first"), record("second"))
+
+	validated, apiSuccess := cons.ProcessSingleMessage(createValidMessageJSON())
+	if !validated || !apiSuccess {
+		t.Fatalf("Expected message processed, got validated=%v apiSuccess=%v", validated, apiSuccess)
+	}
+
+	expected := []string{"first before", "second before", "second after", "first after"}
+	if len(calls) != len(expected) {
+		t.Fatalf("Expected calls %v, got %v", expected, calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("Expected calls %v, got %v", expected, calls)
+			break
+		}
+	}
+	if got := cons.metrics.processed.Value("other"); got != 1 {
+		t.Errorf("Expected built-in metrics middleware to count the message, got %v", got)
+	}
+}
+
+func TestUse_MiddlewareCanShortCircuit(t *testing.T) {
+	cons := New(createTestConfig("http://example.com"), api_client.NewClient("http://example.com"), logger.New("test"))
+	cons.Use(func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) *Result {
+			return &Result{ValidationErr: errDuplicate}
+		}
+	})
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.nacked) != 1 || ack.requeued[0] {
+		t.Errorf("Expected short-circuited message dropped, got nacked=%v requeued=%v", ack.nacked, ack.requeued)
+	}
+}
+
+func TestProcessSingleMessage_RejectionLoggedByChain(t *testing.T) {
+	log := logger.New("test")
+	cons := New(createTestConfig("http://example.com"), api_client.NewClient("http://example.com"), log)
+
+	if validated, _ := cons.ProcessSingleMessage([]byte(`{"invalid": true}`)); validated {
+		t.Fatal("Expected message to fail validation")
+	}
+	if _, ok := findLog(log, "Message rejected"); !ok {
+		t.Error("Expected rejection logged by the logging middleware")
+	}
+	if got := cons.metrics.validationFailures.Value("timestamp", "required"); got != 1 {
+		t.Errorf("Expected validation failure counted by the metrics middleware, got %v", got)
+	}
+}
+
+// stubRegions resolves every coordinate to region, or fails with err
+type stubRegions struct {
+	region string
+	err    error
+}
+
+func (s stubRegions) Region(latitude, longitude float64) (string, error) {
+	return s.region, s.err
+}
+
+// captureRegion starts an API stub recording the region of each request
+func captureRegion(t *testing.T, region *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg validator.WeatherMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		*region = msg.Location.Region
+		w.WriteHeader(http.StatusCreated)
+	}))
+}
+
+func TestHandle_EnrichesRegion(t *testing.T) {
+	var region string
+	server := captureRegion(t, &region)
+	defer server.Close()
+
+	cons := New(createTestConfig(server.URL), api_client.NewClient(server.URL), logger.New("test"))
+	cons.SetRegionLookup(stubRegions{region: "Sudeste"})
+
+	if _, apiSuccess := cons.ProcessSingleMessage(createValidMessageJSON()); !apiSuccess {
+		t.Fatal("Expected message to be sent")
+	}
+	if region != "Sudeste" {
+		t.Errorf("Expected region Sudeste in payload, got %q", region)
+	}
+}
+
+func TestHandle_RegionLookupFailureStillSends(t *testing.T) {
+	region := "unset"
+	server := captureRegion(t, &region)
+	defer server.Close()
+
+	log := logger.New("test")
+	cons := New(createTestConfig(server.URL), api_client.NewClient(server.URL), log)
+	cons.SetRegionLookup(stubRegions{err: errors.New("lookup unavailable")})
+
+	if _, apiSuccess := cons.ProcessSingleMessage(createValidMessageJSON()); !apiSuccess {
+		t.Fatal("Expected message to be sent despite the failed lookup")
+	}
+	if region != "" {
+		t.Errorf("Expected payload without region, got %q", region)
+	}
+	if _, ok := findLog(log, "Failed to look up region"); !ok {
+		t.Error("Expected lookup failure to be logged")
+	}
+}
+
+func BenchmarkProcessSingleMessage_StubAPI(b *testing.B) {
+	cfg := createTestConfig("http://api.invalid/api/weather/logs")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL, api_client.WithStub(http.StatusCreated, 0)), logger.New("bench"))
+	body := createValidMessageJSON()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cons.ProcessSingleMessage(body)
+	}
+}
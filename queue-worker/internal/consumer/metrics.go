@@ -0,0 +1,280 @@
+package consumer
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"queue-worker/internal/metrics"
+	"queue-worker/internal/validator"
+)
+
+// consumerMetrics holds the metrics recorded while processing messages
+type consumerMetrics struct {
+	validationFailures   *metrics.Counter
+	processed            *metrics.Counter
+	failed               *metrics.Counter
+	expired              *metrics.Counter
+	outcomes             *metrics.Counter
+	duration             *metrics.Histogram
+	apiDuration          *metrics.Histogram
+	slowAPIRequests      *metrics.Counter
+	reconnects           *metrics.Counter
+	channelReopens       *metrics.Counter
+	connectionUp         *metrics.Gauge
+	publishConfirmed     *metrics.Counter
+	publishFailed        *metrics.Counter
+	batchBytes           *metrics.Histogram
+	bodyBytes            *metrics.Histogram
+	panics               *metrics.Counter
+	duplicatesSkipped    *metrics.Counter
+	outOfOrder           *metrics.Counter
+	retryBudgetExhausted *metrics.Counter
+	schemaFingerprints   *metrics.Gauge
+	firstMessage         *metrics.Gauge
+	sinkPublished        *metrics.Counter
+	sinkFailed           *metrics.Counter
+}
+
+// bodySizeBuckets suit single message bodies in bytes, from 256 B to 1 MiB
+var bodySizeBuckets = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+// batchSizeBuckets suit API request bodies in bytes, from 1 KiB to 4 MiB
+var batchSizeBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20}
+
+func newConsumerMetrics(reg *metrics.Registry) *consumerMetrics {
+	return &consumerMetrics{
+		validationFailures: reg.NewCounter(
+			"queue_worker_validation_failures_total",
+			"Messages rejected by validation, by failing field and error code.",
+			"field", "code",
+		),
+		processed: reg.NewCounter(
+			"queue_worker_messages_processed_total",
+			"Validated messages accepted by the API, by source.",
+			"source",
+		),
+		failed: reg.NewCounter(
+			"queue_worker_messages_failed_total",
+			"Validated messages the API did not accept, by source.",
+			"source",
+		),
+		expired: reg.NewCounter(
+			"queue_worker_messages_expired_total",
+			"Validated messages older than the maximum message age, by source.",
+			"source",
+		),
+		outcomes: reg.NewCounter(
+			"queue_worker_message_outcomes_total",
+			"Deliveries by how they left the worker: ack, nack_requeue, republish, nack_drop, dlq or quarantine.",
+			"outcome",
+		),
+		duration: reg.NewHistogram(
+			"queue_worker_message_duration_seconds",
+			"Time to process a validated message, by source.",
+			metrics.DefaultBuckets,
+			"source",
+		),
+		apiDuration: reg.NewHistogram(
+			"queue_worker_api_request_duration_seconds",
+			"Duration of each API request, including retries as separate requests.",
+			metrics.DefaultBuckets,
+		),
+		slowAPIRequests: reg.NewCounter(
+			"queue_worker_slow_api_requests_total",
+			"API requests that took longer than the slow API threshold.",
+		),
+		reconnects: reg.NewCounter(
+			"queue_worker_reconnect_total",
+			"RabbitMQ connections re-established after being lost.",
+		),
+		channelReopens: reg.NewCounter(
+			"queue_worker_channel_reopen_total",
+			"Channels reopened after being lost, including after a reconnect.",
+		),
+		connectionUp: reg.NewGauge(
+			"queue_worker_connection_up",
+			"Whether the RabbitMQ connection and channel are usable (0/1).",
+		),
+		publishConfirmed: reg.NewCounter(
+			"queue_worker_publish_confirmed_total",
+			"Republished messages confirmed by the broker.",
+		),
+		publishFailed: reg.NewCounter(
+			"queue_worker_publish_failed_total",
+			"Republished messages the broker nacked, didn't confirm in time, or that failed to send.",
+		),
+		batchBytes: reg.NewHistogram(
+			"queue_worker_batch_size_bytes",
+			"Encoded size of each batch body posted to the API.",
+			batchSizeBuckets,
+		),
+		bodyBytes: reg.NewHistogram(
+			"queue_worker_message_body_bytes",
+			"Raw size of each delivery body, before decoding or validation.",
+			bodySizeBuckets,
+		),
+		panics: reg.NewCounter(
+			"queue_worker_panics_total",
+			"Panics recovered while processing a delivery.",
+		),
+		duplicatesSkipped: reg.NewCounter(
+			"queue_worker_duplicates_skipped_total",
+			"Deliveries acknowledged without processing because the dedup store had already seen their body.",
+		),
+		outOfOrder: reg.NewCounter(
+			"queue_worker_out_of_order_messages_total",
+			"Messages older than the last one processed for the same city, by source.",
+			"source",
+		),
+		retryBudgetExhausted: reg.NewCounter(
+			"queue_worker_retry_budget_exhausted_total",
+			"Retries skipped because the global retry budget was exhausted.",
+		),
+		schemaFingerprints: reg.NewGauge(
+			"queue_worker_schema_fingerprints",
+			"Distinct message shapes (sets of field paths) seen in sampled messages.",
+		),
+		firstMessage: reg.NewGauge(
+			"queue_worker_first_message_processed",
+			"1 once the worker has finished processing its first message, 0 before.",
+		),
+		sinkPublished: reg.NewCounter(
+			"queue_worker_sink_published_total",
+			"Accepted messages published to the secondary sink exchange.",
+		),
+		sinkFailed: reg.NewCounter(
+			"queue_worker_sink_publish_failed_total",
+			"Accepted messages that couldn't be published to the secondary sink exchange.",
+		),
+	}
+}
+
+// otherSource is the metric label for sources outside Config.KnownSources
+const otherSource = "other"
+
+// sourceLabel maps a message source to a metric label, bucketing sources
+// that aren't known so label cardinality stays bounded
+func (c *Consumer) sourceLabel(source string) string {
+	for _, known := range c.config.KnownSources {
+		if source == known {
+			return source
+		}
+	}
+	return otherSource
+}
+
+// observeAPIRequest records the latency of one API request, counting and
+// logging it as slow when it exceeds SlowAPIThreshold
+func (c *Consumer) observeAPIRequest(elapsed time.Duration, statusCode, attempt int) {
+	c.metrics.apiDuration.Observe(elapsed.Seconds())
+
+	if c.config.SlowAPIThreshold <= 0 || elapsed <= c.config.SlowAPIThreshold {
+		return
+	}
+	c.metrics.slowAPIRequests.Inc()
+	c.logger.Warn("Slow API request", map[string]interface{}{
+		"duration_ms":  elapsed.Milliseconds(),
+		"threshold_ms": c.config.SlowAPIThreshold.Milliseconds(),
+		"status_code":  statusCode,
+		"attempt":      attempt,
+	})
+}
+
+// Stats is a point-in-time snapshot of the consumer's metrics and state
+type Stats struct {
+	Processed          uint64                   `json:"processed"`
+	Failed             uint64                   `json:"failed"`
+	DeadLettered       uint64                   `json:"deadLettered"`
+	ValidationFailures []ValidationFailureCount `json:"validationFailures"`
+
+	UptimeSeconds float64    `json:"uptimeSeconds"`
+	Connected     bool       `json:"connected"`
+	Circuit       string     `json:"circuit"`
+	LastSuccess   *time.Time `json:"lastSuccess,omitempty"` // nil until a message is accepted
+	LastFailure   *time.Time `json:"lastFailure,omitempty"` // nil until a message fails
+	Backlog       *int       `json:"backlog,omitempty"`     // nil if the queue can't be inspected
+
+	// FirstMessage is when the first delivery finished processing, nil
+	// until then
+	FirstMessage *time.Time `json:"firstMessage,omitempty"`
+
+	// SchemaFingerprints counts the distinct message shapes seen, zero
+	// unless fingerprinting is enabled
+	SchemaFingerprints int `json:"schemaFingerprints"`
+}
+
+// ValidationFailureCount is the number of rejections for one field and code
+type ValidationFailureCount struct {
+	Field string `json:"field"`
+	Code  string `json:"code"`
+	Count uint64 `json:"count"`
+}
+
+// Stats returns a snapshot of the consumer's metrics
+func (c *Consumer) Stats() Stats {
+	stats := Stats{
+		Processed:     uint64(c.metrics.processed.Total()),
+		Failed:        uint64(c.metrics.failed.Total()),
+		DeadLettered:  uint64(c.metrics.outcomes.Value(outcomeDeadLetter)),
+		UptimeSeconds: time.Since(c.started).Seconds(),
+		Connected:     c.Ready(),
+		Circuit:       string(c.breaker.State()),
+		LastSuccess:   loadTime(&c.lastSuccess),
+		LastFailure:   loadTime(&c.lastFailure),
+		FirstMessage:  loadTime(&c.firstMessage),
+	}
+	if depth, err := c.queueDepth(); err == nil {
+		stats.Backlog = &depth
+	}
+	if c.shapes != nil {
+		stats.SchemaFingerprints = c.shapes.distinct()
+	}
+	for _, s := range c.metrics.validationFailures.Samples() {
+		stats.ValidationFailures = append(stats.ValidationFailures, ValidationFailureCount{
+			Field: s.LabelValues[0],
+			Code:  s.LabelValues[1],
+			Count: uint64(s.Value),
+		})
+	}
+	return stats
+}
+
+// loadTime reads a time stored as Unix nanoseconds, nil if never stored
+func loadTime(unixNano *int64) *time.Time {
+	n := atomic.LoadInt64(unixNano)
+	if n == 0 {
+		return nil
+	}
+	t := time.Unix(0, n).UTC()
+	return &t
+}
+
+// Registry returns the metrics registry to expose for scraping
+func (c *Consumer) Registry() *metrics.Registry {
+	return c.registry
+}
+
+// recordValidationFailure counts a rejected message by its failing field
+func (c *Consumer) recordValidationFailure(err error) {
+	c.metrics.validationFailures.Inc(rejectionReason(err))
+}
+
+// rejectionReason returns the field and code a message was rejected for.
+// Bodies that can't be decoded, are empty or are not JSON have no field and
+// count as "body".
+func rejectionReason(err error) (field, code string) {
+	var validationErr validator.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Field, validationErr.Code
+	}
+	var encodingErr unsupportedEncodingError
+	if errors.As(err, &encodingErr) {
+		return "body", "unsupported_encoding"
+	}
+	var transformErr transformError
+	if errors.As(err, &transformErr) {
+		return "body", "transform_failed"
+	}
+	return "body", "malformed"
+}
@@ -0,0 +1,217 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"queue-worker/internal/config"
+	"queue-worker/internal/validator"
+)
+
+// splitNDJSON returns the non-blank lines of a newline-delimited JSON body.
+// It reports false when NDJSONMode is off, when the body is a single JSON
+// document (which may span lines when pretty-printed) and when it has fewer
+// than two lines, leaving the body to be handled as one message.
+func (c *Consumer) splitNDJSON(body []byte) ([][]byte, bool) {
+	if c.config.NDJSONMode == "" || c.config.NDJSONMode == config.NDJSONOff || json.Valid(body) {
+		return nil, false
+	}
+
+	var lines [][]byte
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, len(lines) > 1
+}
+
+// processLines handles each line of an NDJSON body as its own message and
+// settles the delivery as NDJSONMode says, returning the outcome and whether
+// the ack was batched
+func (c *Consumer) processLines(delivery amqp.Delivery, lines [][]byte) (string, bool) {
+	c.logger.Info("Processing NDJSON message", map[string]interface{}{
+		"delivery_tag": delivery.DeliveryTag,
+		"lines":        len(lines),
+		"mode":         c.config.NDJSONMode,
+	})
+
+	if c.config.NDJSONMode == config.NDJSONBestEffort {
+		return c.processLinesBestEffort(delivery, lines)
+	}
+	return c.processLinesAllOrNothing(delivery, lines)
+}
+
+// processLinesAllOrNothing validates every line before sending any, then
+// sends them in order. The delivery is acked once all are accepted; the
+// first invalid or failing line settles the whole delivery as it would a
+// single message. A retried delivery sends every line again, including those
+// already accepted, unless DedupCacheSize lets the worker skip them.
+func (c *Consumer) processLinesAllOrNothing(delivery amqp.Delivery, lines [][]byte) (string, bool) {
+	for _, line := range lines {
+		if err := c.validateLine(delivery, line); err != nil {
+			// Run the line through the chain for the usual rejection log and metrics
+			result := c.chain(context.Background(), c.lineMessage(delivery, line))
+			if result.ValidationErr != nil {
+				err = result.ValidationErr
+			}
+			return c.settleRejected(delivery, err), false
+		}
+	}
+
+	for _, line := range lines {
+		result := c.chain(context.Background(), c.lineMessage(delivery, line))
+		if result.ValidationErr != nil {
+			return c.settleRejected(delivery, result.ValidationErr), false
+		}
+		if (!result.Success || result.SinkFailed) && !result.Spooled {
+			return c.settleHandled(delivery, result)
+		}
+	}
+	return outcomeAck, c.ack(delivery)
+}
+
+// processLinesBestEffort handles every line and settles each on its own,
+// then acks the delivery. If a line can't be republished, or the schema
+// registry is down, the whole delivery is requeued, so no line is lost;
+// lines already accepted may be sent again.
+func (c *Consumer) processLinesBestEffort(delivery amqp.Delivery, lines [][]byte) (string, bool) {
+	for i, line := range lines {
+		result := c.chain(context.Background(), c.lineMessage(delivery, line))
+		if schemaUnavailable(result.ValidationErr) {
+			// As for a single message, wait out the registry outage
+			c.requeueLater(delivery, c.retryDelay())
+			return outcomeNackRequeue, false
+		}
+		if err := c.settleLine(delivery, line, result); err != nil {
+			c.logger.Error("Failed to settle NDJSON line, requeueing message", map[string]interface{}{
+				"delivery_tag": delivery.DeliveryTag,
+				"line":         i + 1,
+				"error":        err.Error(),
+			})
+			delivery.Nack(false, true)
+			return outcomeNackRequeue, false
+		}
+	}
+	delivery.Ack(false)
+	return outcomeAck, false
+}
+
+// settleLine disposes of one best-effort line that wasn't accepted, by
+// publishing it alone: invalid lines are settled as settleInvalidLine says,
+// expired or rejected lines are dead-lettered when DeadLetterQueue is set,
+// and other failures are retried as retryLine says
+func (c *Consumer) settleLine(delivery amqp.Delivery, line []byte, result *Result) error {
+	single := delivery
+	single.Body = line
+	// The line was taken from the decoded body
+	single.ContentEncoding = ""
+	single.Headers = withoutContentEncoding(delivery.Headers)
+
+	switch {
+	case result.ValidationErr != nil:
+		return c.settleInvalidLine(single, result.ValidationErr)
+	case result.SinkFailed:
+		// Redelivered alone, the line only retries the sink publish
+		return c.retryLine(single)
+	case result.Success || result.Spooled:
+		return nil
+	case result.Expired && c.config.DeadLetterQueue != "":
+		return c.publishFailed(single, c.config.DeadLetterQueue, DLQReasonExpired, "")
+	case result.Expired:
+		return nil
+	case result.Rejected && c.config.DeadLetterQueue != "":
+		return c.publishFailed(single, c.config.DeadLetterQueue, DLQReasonClientError, "")
+	default:
+		return c.retryLine(single)
+	}
+}
+
+// settleInvalidLine settles a line that failed validation the way
+// settleRejected settles a single message with the same body: old schema
+// versions are dead-lettered, RequeueInvalidOnce gives the line one more
+// delivery, and with QuarantineQueue set a broken rule is quarantined and a
+// malformed line dead-lettered. Otherwise the line is dropped.
+func (c *Consumer) settleInvalidLine(line amqp.Delivery, err error) error {
+	field, code := rejectionReason(err)
+	if code == validator.CodeUnsupported {
+		if c.config.DeadLetterQueue == "" {
+			return nil
+		}
+		return c.publishFailed(line, c.config.DeadLetterQueue, DLQReasonValidation, field)
+	}
+	if retries := headerInt(line.Headers, ValidationRetryHeader); c.config.RequeueInvalidOnce && retries < 1 {
+		headers := copyTable(line.Headers)
+		headers[ValidationRetryHeader] = int32(retries + 1)
+		return c.publish("", c.config.QueueName, republishing(line, headers))
+	}
+	if c.config.QuarantineQueue == "" {
+		return nil
+	}
+
+	var validationErr validator.ValidationError
+	if !errors.As(err, &validationErr) {
+		if c.config.DeadLetterQueue == "" {
+			return nil
+		}
+		return c.publishFailed(line, c.config.DeadLetterQueue, DLQReasonMalformed, "")
+	}
+	return c.publishFailed(line, c.config.QuarantineQueue, DLQReasonValidation, validationErr.Field)
+}
+
+// schemaUnavailable reports whether err is a CodeSchemaUnavailable rejection
+func schemaUnavailable(err error) bool {
+	var validationErr validator.ValidationError
+	return errors.As(err, &validationErr) && validationErr.Code == validator.CodeSchemaUnavailable
+}
+
+// retryLine republishes a line the API didn't accept to the queue with its
+// retry count raised, like requeueOrDeadLetter does for a whole message, so
+// the republished line keeps counting when it fails again. Past MaxRetries
+// it is dead-lettered instead, or dropped without DeadLetterQueue.
+func (c *Consumer) retryLine(line amqp.Delivery) error {
+	retries := headerInt(line.Headers, RetryCountHeader)
+	if c.config.MaxRetries > 0 && retries >= c.config.MaxRetries {
+		c.logger.Warn("NDJSON line exceeded max retries, dead-lettering", map[string]interface{}{
+			"delivery_tag": line.DeliveryTag,
+			"retries":      retries,
+			"max_retries":  c.config.MaxRetries,
+		})
+		if c.config.DeadLetterQueue == "" {
+			return nil
+		}
+		return c.publishFailed(line, c.config.DeadLetterQueue, DLQReasonMaxRetries, "")
+	}
+
+	headers := copyTable(line.Headers)
+	headers[RetryCountHeader] = int32(retries + 1)
+	return c.publish("", c.config.QueueName, republishing(line, headers))
+}
+
+// validateLine checks a line as the handler would, without sending it
+func (c *Consumer) validateLine(delivery amqp.Delivery, line []byte) error {
+	body, err := c.transformBody(line)
+	if err != nil {
+		return err
+	}
+	parsed, err := c.validator.ValidateMessage(body)
+	if err != nil {
+		return err
+	}
+	return c.validator.CheckPublisher(parsed, c.messagePublisher(delivery))
+}
+
+// lineMessage builds the handler message for one NDJSON line
+func (c *Consumer) lineMessage(delivery amqp.Delivery, line []byte) *Message {
+	return &Message{
+		DeliveryTag: delivery.DeliveryTag,
+		Body:        line,
+		Headers:     c.forwardHeaders(delivery.Headers),
+		Timestamp:   delivery.Timestamp,
+		Priority:    c.messagePriority(delivery.Headers),
+		Publisher:   c.messagePublisher(delivery),
+	}
+}
@@ -0,0 +1,276 @@
+package consumer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/config"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for newline-delimited JSON bodies
+
+// ndjsonBody joins documents into an NDJSON body with a blank line between
+// the first two
+func ndjsonBody(lines ...[]byte) []byte {
+	body := bytes.Join(lines, []byte("\n"))
+	return bytes.Replace(body, []byte("\n"), []byte("\n\n"), 1)
+}
+
+func TestProcessMessage_NDJSONSendsEachLine(t *testing.T) {
+	for _, mode := range []string{config.NDJSONAllOrNone, config.NDJSONBestEffort} {
+		t.Run(mode, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			cfg := createTestConfig(server.URL)
+			cfg.NDJSONMode = mode
+			cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+			ack := &fakeAcknowledger{}
+
+			body := ndjsonBody(createValidMessageJSON(), createValidMessageJSON(), createValidMessageJSON())
+			cons.processMessage(createDelivery(ack, 1, body))
+
+			if got := requests.Load(); got != 3 {
+				t.Errorf("Expected 3 API requests, got %d", got)
+			}
+			if len(ack.acked) != 1 || len(ack.nacked) != 0 {
+				t.Errorf("Expected the delivery acked once, got acked=%v nacked=%v", ack.acked, ack.nacked)
+			}
+		})
+	}
+}
+
+func TestProcessMessage_NDJSONAllOrNothingRejectsBadLine(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.NDJSONMode = config.NDJSONAllOrNone
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	ack := &fakeAcknowledger{}
+
+	body := ndjsonBody(createValidMessageJSON(), []byte(`{"timestamp":`), createValidMessageJSON())
+	cons.processMessage(createDelivery(ack, 1, body))
+
+	if got := requests.Load(); got != 0 {
+		t.Errorf("Expected no lines sent, got %d requests", got)
+	}
+	if len(ack.nacked) != 1 || ack.requeued[0] {
+		t.Errorf("Expected nack without requeue, got nacked=%v requeued=%v", ack.nacked, ack.requeued)
+	}
+}
+
+func TestProcessMessage_NDJSONBestEffortDeadLettersBadLine(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.NDJSONMode = config.NDJSONBestEffort
+	cfg.DeadLetterQueue = "weather-dlq"
+	cfg.QuarantineQueue = "weather-quarantine"
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	ack := &fakeAcknowledger{}
+
+	bad := []byte(`{"timestamp":`)
+	body := ndjsonBody(createValidMessageJSON(), bad, createValidMessageJSON())
+	cons.processMessage(createDelivery(ack, 1, body))
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected the 2 valid lines sent, got %d requests", got)
+	}
+	if len(ack.acked) != 1 || len(ack.nacked) != 0 {
+		t.Errorf("Expected the delivery acked, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+	if len(pub.published) != 1 || !bytes.Equal(pub.published[0].msg.Body, bad) ||
+		pub.published[0].msg.Headers[DLQReasonHeader] != DLQReasonMalformed {
+		t.Errorf("Expected only the bad line dead-lettered as malformed, got %+v", pub.published)
+	}
+}
+
+func TestProcessMessage_NDJSONInvalidLineSettledLikeSingleMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var msg map[string]interface{}
+	json.Unmarshal(createValidMessageJSON(), &msg)
+	msg["weather"].(map[string]interface{})["humidity"] = 150.0
+	invalid, _ := json.Marshal(msg)
+
+	testCases := []struct {
+		name       string
+		quarantine string
+		queue      string // where the invalid payload goes, "" when dropped
+	}{
+		{name: "quarantine", quarantine: "weather-quarantine", queue: "weather-quarantine"},
+		{name: "dead-letter queue only", queue: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := createTestConfig(server.URL)
+			cfg.NDJSONMode = config.NDJSONBestEffort
+			cfg.DeadLetterQueue = "weather-dlq"
+			cfg.QuarantineQueue = tc.quarantine
+			cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+
+			single := &fakePublisher{}
+			cons.publisher = single
+			cons.processMessage(createDelivery(&fakeAcknowledger{}, 1, invalid))
+
+			lines := &fakePublisher{}
+			cons.publisher = lines
+			cons.processMessage(createDelivery(&fakeAcknowledger{}, 2, ndjsonBody(createValidMessageJSON(), invalid)))
+
+			for name, pub := range map[string]*fakePublisher{"single message": single, "NDJSON line": lines} {
+				if tc.queue == "" {
+					if len(pub.published) != 0 {
+						t.Errorf("%s: expected the invalid payload dropped, got %+v", name, pub.published)
+					}
+					continue
+				}
+				if len(pub.published) != 1 {
+					t.Fatalf("%s: expected one publish, got %+v", name, pub.published)
+				}
+				got := pub.published[0]
+				if got.key != tc.queue || got.msg.Headers[DLQReasonHeader] != DLQReasonValidation || got.msg.Headers[DLQFieldHeader] != "weather.humidity" {
+					t.Errorf("%s: expected %s with reason %s on weather.humidity, got %s %v", name, tc.queue, DLQReasonValidation, got.key, got.msg.Headers)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessMessage_NDJSONRequeuesWhenLineCantBeSettled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.NDJSONMode = config.NDJSONBestEffort
+	cfg.DeadLetterQueue = "weather-dlq"
+	cfg.QuarantineQueue = "weather-quarantine"
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	cons.publisher = &fakePublisher{err: errPublishNacked}
+	ack := &fakeAcknowledger{}
+
+	body := ndjsonBody(createValidMessageJSON(), []byte(`not json`))
+	cons.processMessage(createDelivery(ack, 1, body))
+
+	if len(ack.nacked) != 1 || !ack.requeued[0] {
+		t.Errorf("Expected the delivery requeued, got nacked=%v requeued=%v", ack.nacked, ack.requeued)
+	}
+}
+
+func TestProcessMessage_NDJSONBestEffortCountsLineRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.NDJSONMode = config.NDJSONBestEffort
+	cfg.RetryAttempts = 1
+	cfg.MaxRetries = 2
+	cfg.DeadLetterQueue = "weather-dlq"
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+
+	body := ndjsonBody(createValidMessageJSON(), createValidMessageJSON())
+	first := createDelivery(&fakeAcknowledger{}, 1, body)
+	cons.processMessage(first)
+
+	if len(pub.published) != 2 || pub.published[0].key != "test-queue" || pub.published[0].msg.Headers[RetryCountHeader] != int32(1) {
+		t.Fatalf("Expected both lines republished with retry count 1, got %+v", pub.published)
+	}
+
+	pub.published = nil
+	exhausted := createDelivery(&fakeAcknowledger{}, 2, body)
+	exhausted.Headers = map[string]interface{}{RetryCountHeader: int32(2)}
+	cons.processMessage(exhausted)
+
+	if len(pub.published) != 2 || pub.published[0].key != "weather-dlq" || pub.published[0].msg.Headers[DLQReasonHeader] != DLQReasonMaxRetries {
+		t.Errorf("Expected lines past MaxRetries dead-lettered, got %+v", pub.published)
+	}
+}
+
+func TestProcessMessage_NDJSONRetriedLineDropsEncodingHeader(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.NDJSONMode = config.NDJSONBestEffort
+	cfg.RetryAttempts = 1
+	cfg.MaxRetries = 3
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+
+	// The encoding is declared only as a header
+	delivery := createDelivery(&fakeAcknowledger{}, 1, gzipBody(t, ndjsonBody(createValidMessageJSON(), createValidMessageJSON())))
+	delivery.Headers = map[string]interface{}{"Content-Encoding": "gzip"}
+	cons.processMessage(delivery)
+
+	if len(pub.published) != 2 {
+		t.Fatalf("Expected both lines republished, got %d", len(pub.published))
+	}
+	retried := pub.published[0].msg
+	if _, ok := retried.Headers["Content-Encoding"]; ok {
+		t.Errorf("Expected the content-encoding header dropped from the plain line, got %v", retried.Headers)
+	}
+
+	failing.Store(false)
+	ack := &fakeAcknowledger{}
+	redelivery := createDelivery(ack, 2, retried.Body)
+	redelivery.Headers = retried.Headers
+	cons.processMessage(redelivery)
+
+	if len(ack.acked) != 1 || len(ack.nacked) != 0 {
+		t.Errorf("Expected the redelivered line sent and acked, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+}
+
+func TestSplitNDJSON_LeavesSingleDocumentsAlone(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.NDJSONMode = config.NDJSONAllOrNone
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+
+	pretty := []byte("{\n  \"timestamp\": \"2025-12-03T14:30:00Z\"\n}")
+	if _, ok := cons.splitNDJSON(pretty); ok {
+		t.Error("Expected a pretty-printed document to be left whole")
+	}
+	if _, ok := cons.splitNDJSON(append(createValidMessageJSON(), '\n', '\n')); ok {
+		t.Error("Expected a single line with trailing blank lines to be left whole")
+	}
+}
@@ -0,0 +1,85 @@
+package consumer
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"queue-worker/internal/validator"
+)
+
+// cityClock remembers the latest reading timestamp processed per city,
+// forgetting the least recently seen city once it tracks capacity of them
+type cityClock struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // *cityReading, most recently seen first
+	entries map[string]*list.Element
+}
+
+// cityReading is the latest timestamp processed for a city
+type cityReading struct {
+	city   string
+	latest time.Time
+}
+
+func newCityClock(capacity int) *cityClock {
+	return &cityClock{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// observe records a reading for city taken at t. When t is older than the
+// latest reading already seen for the city it returns that reading and true,
+// keeping the later one.
+func (c *cityClock) observe(city string, t time.Time) (latest time.Time, outOfOrder bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[city]; ok {
+		c.order.MoveToFront(elem)
+		reading := elem.Value.(*cityReading)
+		if t.Before(reading.latest) {
+			return reading.latest, true
+		}
+		reading.latest = t
+		return t, false
+	}
+
+	c.entries[city] = c.order.PushFront(&cityReading{city: city, latest: t})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cityReading).city)
+	}
+	return t, false
+}
+
+// checkOrdering warns about a message older than the last one processed for
+// its city. It only reports: the message is processed as usual.
+func (c *Consumer) checkOrdering(deliveryTag uint64, msg *validator.WeatherMessage) {
+	if c.ordering == nil {
+		return
+	}
+	t, ok := c.validator.ParseTimestamp(msg.Timestamp)
+	if !ok {
+		return
+	}
+
+	latest, outOfOrder := c.ordering.observe(msg.Location.City, t)
+	if !outOfOrder {
+		return
+	}
+	c.metrics.outOfOrder.Inc(c.sourceLabel(msg.Source))
+	c.logger.Warn("Message older than last processed for city", map[string]interface{}{
+		"delivery_tag": deliveryTag,
+		"city":         msg.Location.City,
+		"source":       msg.Source,
+		"timestamp":    msg.Timestamp,
+		"last_seen":    latest.UTC().Format(time.RFC3339Nano),
+		"behind_ms":    latest.Sub(t).Milliseconds(),
+	})
+}
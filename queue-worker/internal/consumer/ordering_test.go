@@ -0,0 +1,89 @@
+package consumer
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for detecting out-of-order readings per city
+
+func TestCityClock_DetectsOlderReading(t *testing.T) {
+	clock := newCityClock(10)
+	noon := time.Date(2025, 12, 3, 12, 0, 0, 0, time.UTC)
+
+	clock.observe("Recife", noon)
+	if _, outOfOrder := clock.observe("Recife", noon.Add(time.Hour)); outOfOrder {
+		t.Error("Expected a later reading to be in order")
+	}
+	latest, outOfOrder := clock.observe("Recife", noon)
+	if !outOfOrder || !latest.Equal(noon.Add(time.Hour)) {
+		t.Errorf("Expected out of order behind %v, got %v (outOfOrder=%v)", noon.Add(time.Hour), latest, outOfOrder)
+	}
+	if _, outOfOrder := clock.observe("Natal", noon); outOfOrder {
+		t.Error("Expected cities to be tracked separately")
+	}
+}
+
+func TestCityClock_ForgetsLeastRecentlySeenCity(t *testing.T) {
+	clock := newCityClock(2)
+	noon := time.Date(2025, 12, 3, 12, 0, 0, 0, time.UTC)
+
+	clock.observe("Recife", noon)
+	clock.observe("Natal", noon)
+	clock.observe("Recife", noon) // Recife is now more recent than Natal
+	clock.observe("Salvador", noon)
+
+	if len(clock.entries) != 2 {
+		t.Errorf("Expected 2 cities tracked, got %d", len(clock.entries))
+	}
+	if _, outOfOrder := clock.observe("Natal", noon.Add(-time.Hour)); outOfOrder {
+		t.Error("Expected Natal to have been forgotten")
+	}
+}
+
+func TestProcessMessage_WarnsButProcessesOutOfOrderMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	log := logger.New("test")
+	cfg := createTestConfig(server.URL)
+	cfg.OrderingCheckCities = 10
+	cons := New(cfg, api_client.NewClient(server.URL), log)
+	ack := &fakeAcknowledger{}
+
+	later := bytes.Replace(createValidMessageJSON(), []byte("2025-12-03T14:30:00Z"), []byte("2025-12-03T15:30:00Z"), 1)
+	cons.processMessage(createDelivery(ack, 1, later))
+	cons.processMessage(createDelivery(ack, 2, createValidMessageJSON()))
+
+	if len(ack.acked) != 2 {
+		t.Errorf("Expected both messages processed and acked, got %v", ack.acked)
+	}
+	entry, ok := findLog(log, "Message older than last processed for city")
+	if !ok || entry.Level != logger.WARN {
+		t.Fatalf("Expected out-of-order warning, got %+v", entry)
+	}
+	if entry.Context["city"] != "São Paulo" || entry.Context["behind_ms"] != int64(time.Hour/time.Millisecond) {
+		t.Errorf("Expected São Paulo an hour behind, got %+v", entry.Context)
+	}
+	if got := cons.metrics.outOfOrder.Total(); got != 1 {
+		t.Errorf("Expected 1 out-of-order message, got %v", got)
+	}
+}
+
+func TestProcessMessage_OrderingCheckDisabledByDefault(t *testing.T) {
+	log := logger.New("test")
+	cfg := createTestConfig("http://example.com")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+
+	if cons.ordering != nil {
+		t.Error("Expected no ordering check without OrderingCheckCities")
+	}
+}
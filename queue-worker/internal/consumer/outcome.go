@@ -0,0 +1,107 @@
+package consumer
+
+import (
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Processing outcomes describe how a delivery left the worker
+const (
+	outcomeAck         = "ack"
+	outcomeNackRequeue = "nack_requeue"
+	outcomeNackDrop    = "nack_drop"
+	outcomeDeadLetter  = "dlq"
+	outcomeQuarantine  = "quarantine"
+	// outcomeRepublish is a delivery acked after a copy was republished to
+	// the queue for another try
+	outcomeRepublish = "republish"
+)
+
+// messageOutcome collects what happened to one delivery for the outcome log
+type messageOutcome struct {
+	deliveryTag uint64
+	city        string
+	source      string
+	validated   bool
+	// schemaVersion is the message's schema version, zero until validated
+	schemaVersion int
+	attempts      int
+	statusCode    int
+	// requestID is the X-Request-Id sent with the last API attempt
+	requestID string
+	outcome   string
+	started   time.Time
+	// headers is the sanitized copy of the delivery headers, nil unless
+	// LogOutcomeHeaders is set
+	headers map[string]interface{}
+}
+
+// finishOutcome counts the delivery's outcome and logs it. processMessage
+// defers it, so every delivery is counted exactly once whichever way it
+// returns.
+func (c *Consumer) finishOutcome(o *messageOutcome) {
+	c.metrics.outcomes.Inc(o.outcome)
+	c.logOutcome(o)
+	c.markFirstMessage(o)
+}
+
+// logOutcome emits the single summary line for a processed delivery: Info
+// when it was acknowledged, Warn otherwise
+func (c *Consumer) logOutcome(o *messageOutcome) {
+	if !c.config.LogMessageOutcome {
+		return
+	}
+
+	context := map[string]interface{}{
+		"delivery_tag":   o.deliveryTag,
+		"city":           o.city,
+		"source":         o.source,
+		"validated":      o.validated,
+		"schema_version": o.schemaVersion,
+		"attempts":       o.attempts,
+		"status_code":    o.statusCode,
+		"outcome":        o.outcome,
+		"duration_ms":    time.Since(o.started).Milliseconds(),
+	}
+	if o.requestID != "" && o.outcome != outcomeAck {
+		// Failures carry the last attempt's id so it can be looked up in the API's logs
+		context["request_id"] = o.requestID
+	}
+	if o.headers != nil {
+		context["headers"] = o.headers
+	}
+
+	if o.outcome == outcomeAck {
+		c.logger.Info("Message outcome", context)
+	} else {
+		c.logger.Warn("Message outcome", context)
+	}
+}
+
+// outcomeHeaders copies the delivery headers for the outcome log, dropping
+// the denylisted ones. It returns nil unless LogOutcomeHeaders is set.
+func (c *Consumer) outcomeHeaders(table amqp.Table) map[string]interface{} {
+	if !c.config.LogOutcomeHeaders {
+		return nil
+	}
+
+	headers := make(map[string]interface{}, len(table))
+	for key, value := range table {
+		if !c.deniedHeader(key) {
+			headers[key] = value
+		}
+	}
+	return headers
+}
+
+// deniedHeader reports whether key is in LogHeaderDenylist
+func (c *Consumer) deniedHeader(key string) bool {
+	for _, denied := range c.config.LogHeaderDenylist {
+		if strings.EqualFold(key, denied) {
+			return true
+		}
+	}
+	return false
+}
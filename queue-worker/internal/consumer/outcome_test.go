@@ -0,0 +1,60 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the per-delivery outcome metric
+
+func TestProcessMessage_CountsEachOutcomeOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		body    []byte
+		fail    bool
+		dlq     string
+		outcome string
+	}{
+		{name: "accepted", body: createValidMessageJSON(), outcome: outcomeAck},
+		{name: "invalid", body: []byte(`{"invalid": true}`), outcome: outcomeNackDrop},
+		{name: "api failure", body: createValidMessageJSON(), fail: true, outcome: outcomeNackRequeue},
+		{name: "undecodable", body: []byte{0x1f, 0x8b, 0x00}, dlq: "weather-dlq", outcome: outcomeDeadLetter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createTestConfig(server.URL)
+			cfg.RetryAttempts = 1
+			cfg.DeadLetterQueue = tt.dlq
+			cfg.ForwardHeaders = []string{"X-Fail"}
+			cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+			cons.publisher = &fakePublisher{}
+
+			delivery := createDelivery(&fakeAcknowledger{}, 1, tt.body)
+			if tt.fail {
+				delivery.Headers = map[string]interface{}{"X-Fail": "1"}
+			}
+			cons.processMessage(delivery)
+
+			if got := cons.metrics.outcomes.Value(tt.outcome); got != 1 {
+				t.Errorf("Expected one %s outcome, got %v", tt.outcome, got)
+			}
+			if got := cons.metrics.outcomes.Total(); got != 1 {
+				t.Errorf("Expected exactly one outcome counted, got %v", got)
+			}
+		})
+	}
+}
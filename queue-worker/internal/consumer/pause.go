@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"queue-worker/internal/breaker"
+)
+
+// pausePollInterval is how often a paused consumer checks whether the circuit
+// has moved to half-open or Shutdown was called
+const pausePollInterval = 100 * time.Millisecond
+
+// waitWhilePaused blocks the delivery loop while the API circuit is open,
+// either because PauseOnBreakerOpen is set or because a message is held. With
+// a prefetch limit the broker then stops sending, relieving the broker and the
+// API together. Once the circuit is half-open the next message is the probe:
+// if it fails the circuit reopens and consumption pauses again. It reports
+// false if the consumer started shutting down while paused.
+func (c *Consumer) waitWhilePaused() bool {
+	paused := c.config.PauseOnBreakerOpen || atomic.LoadInt32(&c.holding) > 0
+	if !paused || c.breaker.State() != breaker.Open {
+		return true
+	}
+
+	started := time.Now()
+	c.logger.Warn("Consumption paused", map[string]interface{}{
+		"reason":           "api_circuit_open",
+		"last_status_code": atomic.LoadInt32(&c.lastFailedStatus),
+		"cooldown_ms":      c.config.BreakerOpenTimeout.Milliseconds(),
+	})
+
+	for c.breaker.State() == breaker.Open {
+		if c.stopping() {
+			return false
+		}
+		time.Sleep(pausePollInterval)
+	}
+
+	c.logger.Info("Consumption resumed", map[string]interface{}{
+		"reason":    "api_circuit_half_open",
+		"paused_ms": time.Since(started).Milliseconds(),
+	})
+	return true
+}
+
+// shouldHold reports whether a message that failed against the API with
+// statusCode should be held instead of nacked: HoldOnServerErrors is set, it
+// failed with a server error and the failures have opened the circuit
+func (c *Consumer) shouldHold(statusCode int) bool {
+	return c.config.HoldOnServerErrors && statusCode >= 500 && c.breaker.State() == breaker.Open
+}
+
+// holdAndRequeue keeps delivery unsettled while the API circuit is open,
+// pausing the delivery loop in waitWhilePaused so it takes no more messages
+// to nack, then requeues it once the circuit turns half-open or the consumer
+// starts shutting down. The wait happens off the delivery loop. The Error log
+// is the alert that the API is persistently failing.
+func (c *Consumer) holdAndRequeue(delivery amqp.Delivery) {
+	started := time.Now()
+	c.logger.Error("API persistently failing, holding consumption", map[string]interface{}{
+		"delivery_tag":     delivery.DeliveryTag,
+		"last_status_code": atomic.LoadInt32(&c.lastFailedStatus),
+		"threshold":        c.config.BreakerThreshold,
+		"cooldown_ms":      c.config.BreakerOpenTimeout.Milliseconds(),
+	})
+
+	atomic.AddInt64(&c.deferred, 1)
+	atomic.AddInt32(&c.holding, 1)
+	go func() {
+		defer atomic.AddInt64(&c.deferred, -1)
+		defer atomic.AddInt32(&c.holding, -1)
+		for c.breaker.State() == breaker.Open && !c.stopping() {
+			time.Sleep(pausePollInterval)
+		}
+
+		c.logger.Info("Held message requeued", map[string]interface{}{
+			"delivery_tag": delivery.DeliveryTag,
+			"held_ms":      time.Since(started).Milliseconds(),
+		})
+		delivery.Nack(false, true)
+	}()
+}
@@ -0,0 +1,165 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for pausing consumption while the API circuit is open
+
+func newPausingConsumer(log *logger.Logger) *Consumer {
+	cfg := createTestConfig("http://example.com")
+	cfg.BreakerThreshold = 1
+	cfg.BreakerOpenTimeout = 50 * time.Millisecond
+	cfg.PauseOnBreakerOpen = true
+	return New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+}
+
+func TestWaitWhilePaused_ResumesWhenHalfOpen(t *testing.T) {
+	log := logger.New("test")
+	cons := newPausingConsumer(log)
+	atomic.StoreInt32(&cons.lastFailedStatus, 503)
+	cons.breaker.RecordFailure()
+
+	started := time.Now()
+	if !cons.waitWhilePaused() {
+		t.Fatal("Expected consumption to resume")
+	}
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected pause to last the open timeout, resumed after %v", elapsed)
+	}
+
+	paused, ok := findLog(log, "Consumption paused")
+	if !ok || paused.Context["reason"] != "api_circuit_open" || paused.Context["last_status_code"] != int32(503) {
+		t.Errorf("Expected pause log with trigger reason, got %+v", paused)
+	}
+	if resumed, ok := findLog(log, "Consumption resumed"); !ok || resumed.Context["reason"] != "api_circuit_half_open" {
+		t.Errorf("Expected resume log, got %+v", resumed)
+	}
+}
+
+func TestWaitWhilePaused_StopsOnShutdown(t *testing.T) {
+	cons := newPausingConsumer(logger.New("test"))
+	cons.breaker.RecordFailure()
+	atomic.StoreInt32(&cons.shuttingDown, 1)
+
+	if cons.waitWhilePaused() {
+		t.Error("Expected waitWhilePaused to give up when shutting down")
+	}
+}
+
+func TestWaitWhilePaused_DisabledByDefault(t *testing.T) {
+	log := logger.New("test")
+	cfg := createTestConfig("http://example.com")
+	cfg.BreakerThreshold = 1
+	cfg.BreakerOpenTimeout = time.Hour
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+	cons.breaker.RecordFailure()
+
+	if !cons.waitWhilePaused() {
+		t.Error("Expected no pause when PauseOnBreakerOpen is off")
+	}
+	if _, ok := findLog(log, "Consumption paused"); ok {
+		t.Error("Expected no pause log")
+	}
+}
+
+func TestProcessMessage_HoldsOnServerErrorsOnceCircuitOpens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	log := logger.New("test")
+	cfg := createTestConfig(server.URL)
+	cfg.RetryAttempts = 1
+	cfg.BreakerThreshold = 1
+	cfg.BreakerOpenTimeout = 50 * time.Millisecond
+	cfg.HoldOnServerErrors = true
+	cons := New(cfg, api_client.NewClient(server.URL), log)
+	ack := &fakeAcknowledger{}
+
+	started := time.Now()
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+	if atomic.LoadInt32(&cons.holding) != 1 || len(ack.nacked) != 0 {
+		t.Fatalf("Expected the message held unsettled, got nacked=%v", ack.nacked)
+	}
+
+	if !cons.waitWhilePaused() {
+		t.Fatal("Expected the delivery loop to resume once the circuit turned half-open")
+	}
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the delivery loop paused while holding, resumed after %v", elapsed)
+	}
+	for atomic.LoadInt64(&cons.deferred) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if len(ack.requeued) != 1 || !ack.requeued[0] {
+		t.Errorf("Expected held message requeued, got %v", ack.requeued)
+	}
+	entry, ok := findLog(log, "API persistently failing, holding consumption")
+	if !ok || entry.Level != logger.ERROR || entry.Context["last_status_code"] != int32(503) {
+		t.Errorf("Expected alert log with the last status code, got %+v", entry)
+	}
+}
+
+func TestProcessMessage_HoldTakesPrecedenceOverMaxRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.RetryAttempts = 1
+	cfg.MaxRetries = 3
+	cfg.BreakerThreshold = 1
+	cfg.BreakerOpenTimeout = time.Hour
+	cfg.HoldOnServerErrors = true
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if atomic.LoadInt32(&cons.holding) != 1 || len(pub.published) != 0 {
+		t.Errorf("Expected the message held rather than republished, got %d published", len(pub.published))
+	}
+	cons.stop()
+	for atomic.LoadInt64(&cons.deferred) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if len(ack.requeued) != 1 || !ack.requeued[0] {
+		t.Errorf("Expected held message requeued on shutdown, got %v", ack.requeued)
+	}
+}
+
+func TestProcessMessage_NoHoldByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	log := logger.New("test")
+	cfg := createTestConfig(server.URL)
+	cfg.RetryAttempts = 1
+	cfg.BreakerThreshold = 1
+	cfg.BreakerOpenTimeout = time.Hour
+	cons := New(cfg, api_client.NewClient(server.URL), log)
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.requeued) != 1 || !ack.requeued[0] {
+		t.Errorf("Expected message requeued, got %v", ack.requeued)
+	}
+	if _, ok := findLog(log, "API persistently failing, holding consumption"); ok {
+		t.Error("Expected no hold without CIRCUIT_BREAKER_HOLD_ON_5XX")
+	}
+}
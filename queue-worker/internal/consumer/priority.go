@@ -0,0 +1,32 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"queue-worker/internal/api_client"
+)
+
+// messagePriority reads the message's priority from the PriorityHeader
+// delivery header, lowercased so it matches APIPriorityTimeouts keys
+func (c *Consumer) messagePriority(table amqp.Table) string {
+	if c.config.PriorityHeader == "" {
+		return ""
+	}
+	value, ok := table[c.config.PriorityHeader]
+	if !ok || value == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(fmt.Sprint(value)))
+}
+
+// withPriorityTimeout sets the API request timeout mapped to priority on ctx.
+// Priorities that aren't mapped keep the client's default timeout.
+func (c *Consumer) withPriorityTimeout(ctx context.Context, priority string) context.Context {
+	if timeout, ok := c.config.APIPriorityTimeouts[priority]; ok && priority != "" {
+		return api_client.WithRequestTimeout(ctx, timeout)
+	}
+	return ctx
+}
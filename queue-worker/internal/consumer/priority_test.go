@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for per-priority API timeouts
+
+func TestProcessMessage_PriorityTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.PriorityHeader = "x-message-priority"
+	cfg.APIPriorityTimeouts = map[string]time.Duration{"high": 10 * time.Millisecond}
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	cons.SetRetryPolicy(fixedRetries(0))
+
+	testCases := []struct {
+		name     string
+		headers  amqp.Table
+		accepted bool
+	}{
+		{"priority message times out", amqp.Table{"x-message-priority": "HIGH"}, false},
+		{"unmapped priority uses default", amqp.Table{"x-message-priority": "low"}, true},
+		{"no priority uses default", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ack := &fakeAcknowledger{}
+			delivery := createDelivery(ack, 1, createValidMessageJSON())
+			delivery.Headers = tc.headers
+
+			cons.processMessage(delivery)
+
+			if accepted := len(ack.acked) == 1; accepted != tc.accepted {
+				t.Errorf("Expected accepted=%v, got acked=%v nacked=%v", tc.accepted, ack.acked, ack.nacked)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package consumer
+
+import (
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// messagePublisher returns the publisher id a message's source is checked
+// against: the PublisherHeader header when configured, else the AMQP app-id
+func (c *Consumer) messagePublisher(delivery amqp.Delivery) string {
+	if c.config.PublisherHeader == "" {
+		return delivery.AppId
+	}
+	if value, ok := delivery.Headers[c.config.PublisherHeader]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+	"queue-worker/internal/validator"
+)
+
+// Unit tests for checking the source against the delivery's publisher
+
+func TestProcessMessage_RejectsSourceNotMatchingPublisher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		header   string
+		delivery func(d *amqp.Delivery)
+		rejected bool
+	}{
+		{"app-id matches", "", func(d *amqp.Delivery) { d.AppId = "open-meteo" }, false},
+		{"app-id mismatch", "", func(d *amqp.Delivery) { d.AppId = "openweather" }, true},
+		{"no app-id", "", func(d *amqp.Delivery) {}, true},
+		{"header matches", "x-publisher", func(d *amqp.Delivery) {
+			d.AppId = "something-else"
+			d.Headers = amqp.Table{"x-publisher": "open-meteo"}
+		}, false},
+		{"header mismatch", "x-publisher", func(d *amqp.Delivery) {
+			d.Headers = amqp.Table{"x-publisher": "openweather"}
+		}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := createTestConfig(server.URL)
+			cfg.RequireSourceMatchesPublisher = true
+			cfg.PublisherHeader = tc.header
+			cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+			ack := &fakeAcknowledger{}
+
+			delivery := createDelivery(ack, 1, createValidMessageJSON())
+			tc.delivery(&delivery)
+			cons.processMessage(delivery)
+
+			rejected := cons.metrics.validationFailures.Value("source", validator.CodeInconsistent) == 1
+			if rejected != tc.rejected {
+				t.Errorf("Expected rejected=%v, got acked=%v nacked=%v", tc.rejected, ack.acked, ack.nacked)
+			}
+			if !tc.rejected && len(ack.acked) != 1 {
+				t.Errorf("Expected the matching message sent and acked, got %v", ack.acked)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// errPublishNacked is returned when the broker refuses to take a message
+var errPublishNacked = errors.New("publish nacked by broker")
+
+// confirmingPublisher publishes on a channel in confirm mode and waits for
+// the broker to confirm each message before returning
+type confirmingPublisher struct {
+	ch      *amqp.Channel
+	timeout time.Duration
+}
+
+// PublishWithContext implements Publisher
+func (p *confirmingPublisher) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	confirmation, err := p.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("waiting for publish confirm: %w", err)
+	}
+	if !acked {
+		return errPublishNacked
+	}
+	return nil
+}
+
+// usePublisher publishes through ch, putting it in confirm mode first when
+// PublisherConfirms is set
+func (c *Consumer) usePublisher(ch *amqp.Channel) error {
+	if !c.config.PublisherConfirms {
+		c.publisher = ch
+		return nil
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		c.logger.Error("Failed to enable publisher confirms", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return err
+	}
+	c.publisher = &confirmingPublisher{ch: ch, timeout: c.config.PublishConfirmTimeout}
+	return nil
+}
+
+// publish republishes a message to the broker. With PublisherConfirms set,
+// the outcome is counted and failures are logged with their destination.
+func (c *Consumer) publish(exchange, key string, msg amqp.Publishing) error {
+	err := c.publisher.PublishWithContext(context.Background(), exchange, key, false, false, msg)
+	if !c.config.PublisherConfirms {
+		return err
+	}
+
+	if err != nil {
+		c.metrics.publishFailed.Inc()
+		c.logger.Error("Publish not confirmed by broker", map[string]interface{}{
+			"exchange":    exchange,
+			"routing_key": key,
+			"error":       err.Error(),
+		})
+		return err
+	}
+	c.metrics.publishConfirmed.Inc()
+	return nil
+}
@@ -0,0 +1,49 @@
+package consumer
+
+import (
+	"errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"queue-worker/internal/validator"
+)
+
+// settleInvalid disposes of a message that failed validation once
+// QuarantineQueue is set: a parsed message that broke a validation rule is
+// quarantined for review, while a body that isn't valid JSON is
+// dead-lettered. It reports false without QuarantineQueue, leaving the
+// message to the default handling.
+func (c *Consumer) settleInvalid(delivery amqp.Delivery, err error) (string, bool) {
+	if c.config.QuarantineQueue == "" {
+		return "", false
+	}
+
+	var validationErr validator.ValidationError
+	if !errors.As(err, &validationErr) {
+		return c.deadLetter(delivery, DLQReasonMalformed, ""), true
+	}
+	return c.quarantine(delivery, validationErr), true
+}
+
+// quarantine moves a message that broke a validation rule to the quarantine
+// queue, with the same x-dlq-* headers as the dead-letter queue. If the
+// publish fails the message is requeued rather than lost.
+func (c *Consumer) quarantine(delivery amqp.Delivery, validationErr validator.ValidationError) string {
+	if err := c.publishFailed(delivery, c.config.QuarantineQueue, DLQReasonValidation, validationErr.Field); err != nil {
+		c.logger.Error("Failed to publish message to quarantine queue", map[string]interface{}{
+			"delivery_tag": delivery.DeliveryTag,
+			"queue":        c.config.QuarantineQueue,
+			"error":        err.Error(),
+		})
+		delivery.Nack(false, true)
+		return outcomeNackRequeue
+	}
+
+	c.logger.Warn("Message quarantined", map[string]interface{}{
+		"delivery_tag": delivery.DeliveryTag,
+		"queue":        c.config.QuarantineQueue,
+		"field":        validationErr.Field,
+		"code":         validationErr.Code,
+	})
+	delivery.Ack(false)
+	return outcomeQuarantine
+}
@@ -0,0 +1,88 @@
+package consumer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for quarantining messages that break validation rules
+
+func newQuarantineConsumer(log *logger.Logger) (*Consumer, *fakePublisher) {
+	cfg := createTestConfig("http://example.com")
+	cfg.DeadLetterQueue = "weather-dlq"
+	cfg.QuarantineQueue = "weather-quarantine"
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	return cons, pub
+}
+
+func createOutOfRangeMessageJSON() []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createValidMessageJSON(), &msg)
+	msg["weather"].(map[string]interface{})["humidity"] = 150.0
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestProcessMessage_QuarantinesRuleFailures(t *testing.T) {
+	log := logger.New("test")
+	cons, pub := newQuarantineConsumer(log)
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createOutOfRangeMessageJSON()))
+
+	if len(pub.published) != 1 || pub.published[0].key != "weather-quarantine" {
+		t.Fatalf("Expected message published to the quarantine queue, got %+v", pub.published)
+	}
+	headers := pub.published[0].msg.Headers
+	if headers[DLQReasonHeader] != DLQReasonValidation || headers[DLQFieldHeader] != "weather.humidity" {
+		t.Errorf("Expected validation reason and failing field headers, got %v", headers)
+	}
+	if len(ack.acked) != 1 || len(ack.nacked) != 0 {
+		t.Errorf("Expected original acked after quarantine, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+	if got := cons.metrics.outcomes.Value(outcomeQuarantine); got != 1 {
+		t.Errorf("Expected 1 quarantine outcome, got %v", got)
+	}
+	if entry, ok := findLog(log, "Message quarantined"); !ok || entry.Context["code"] != "out_of_range" {
+		t.Errorf("Expected quarantine log with the rule code, got %+v", entry)
+	}
+}
+
+func TestProcessMessage_DeadLettersMalformedJSONWithQuarantine(t *testing.T) {
+	cons, pub := newQuarantineConsumer(logger.New("test"))
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, []byte(`{"timestamp": `)))
+
+	if len(pub.published) != 1 || pub.published[0].key != "weather-dlq" {
+		t.Fatalf("Expected malformed message published to the DLQ, got %+v", pub.published)
+	}
+	if reason := pub.published[0].msg.Headers[DLQReasonHeader]; reason != DLQReasonMalformed {
+		t.Errorf("Expected %s=%s, got %v", DLQReasonHeader, DLQReasonMalformed, reason)
+	}
+	if got := cons.metrics.outcomes.Value(outcomeDeadLetter); got != 1 {
+		t.Errorf("Expected 1 dlq outcome, got %v", got)
+	}
+}
+
+func TestProcessMessage_DropsRuleFailuresWithoutQuarantine(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createOutOfRangeMessageJSON()))
+
+	if len(pub.published) != 0 {
+		t.Errorf("Expected nothing published, got %+v", pub.published)
+	}
+	if len(ack.nacked) != 1 || ack.requeued[0] {
+		t.Errorf("Expected nack without requeue, got nacked=%v requeued=%v", ack.nacked, ack.requeued)
+	}
+}
@@ -0,0 +1,31 @@
+package consumer
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// recoverPanic is deferred by processMessage so a panic while handling one
+// delivery, such as a bug in a middleware, can't crash the worker. It logs
+// the panic with its stack trace and requeues the delivery, unless it was
+// settled before the panic.
+func (c *Consumer) recoverPanic(delivery amqp.Delivery, outcome *messageOutcome) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	c.metrics.panics.Inc()
+	c.logger.Error("Recovered from panic while processing message", map[string]interface{}{
+		"delivery_tag": delivery.DeliveryTag,
+		"panic":        fmt.Sprint(r),
+		"stack":        string(debug.Stack()),
+		"settled":      outcome.outcome != "",
+	})
+	if outcome.outcome == "" {
+		delivery.Nack(false, true)
+		outcome.outcome = outcomeNackRequeue
+	}
+}
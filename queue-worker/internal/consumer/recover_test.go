@@ -0,0 +1,59 @@
+package consumer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for recovering from panics while processing a delivery
+
+func TestProcessMessage_RecoversFromPanic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	log := logger.New("test")
+	cons := New(createTestConfig(server.URL), api_client.NewClient(server.URL), log)
+	panicking := true
+	cons.Use(func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) *Result {
+			if panicking {
+				var regions map[string]string
+				regions["sudeste"] = msg.Headers["region"]
+			}
+			return next(ctx, msg)
+		}
+	})
+
+	first := &fakeAcknowledger{}
+	cons.processMessage(createDelivery(first, 1, createValidMessageJSON()))
+
+	if len(first.nacked) != 1 || !first.requeued[0] {
+		t.Errorf("Expected panicking message nacked with requeue, got nacked=%v requeued=%v", first.nacked, first.requeued)
+	}
+	entry, ok := findLog(log, "Recovered from panic while processing message")
+	if !ok || entry.Level != logger.ERROR || !strings.Contains(entry.Context["stack"].(string), "recover_test.go") {
+		t.Errorf("Expected Error log with the stack trace, got %+v", entry)
+	}
+	if got := cons.metrics.panics.Value(); got != 1 {
+		t.Errorf("Expected 1 recovered panic, got %v", got)
+	}
+	if got := cons.metrics.outcomes.Value(outcomeNackRequeue); got != 1 {
+		t.Errorf("Expected the panic counted as nack_requeue, got %v", got)
+	}
+
+	// The worker keeps processing later deliveries
+	panicking = false
+	second := &fakeAcknowledger{}
+	cons.processMessage(createDelivery(second, 2, createValidMessageJSON()))
+	if len(second.acked) != 1 {
+		t.Errorf("Expected next message acked, got acked=%v nacked=%v", second.acked, second.nacked)
+	}
+}
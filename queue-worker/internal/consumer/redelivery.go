@@ -0,0 +1,222 @@
+package consumer
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RetryCountHeader counts how many times the worker has republished a message
+// after failing to deliver it to the API
+const RetryCountHeader = "x-retry-count"
+
+// Headers set on messages published to the dead-letter queue, so triage can
+// tell why each message ended up there
+const (
+	DLQReasonHeader        = "x-dlq-reason"
+	DLQFieldHeader         = "x-dlq-field" // failing field, for validation failures
+	DLQOriginalQueueHeader = "x-original-queue"
+	DLQFailedAtHeader      = "x-failed-at" // RFC3339 UTC
+)
+
+// Dead-letter reasons, the values of DLQReasonHeader
+const (
+	DLQReasonValidation  = "validation"
+	DLQReasonClientError = "client-error"
+	DLQReasonMaxRetries  = "max-retries"
+	DLQReasonOversize    = "oversize"
+	DLQReasonDecode      = "decode"
+	DLQReasonExpired     = "expired"
+	DLQReasonMalformed   = "malformed"
+)
+
+// ValidationRetryHeader counts how many times a message that failed
+// validation has been requeued (see Config.RequeueInvalidOnce)
+const ValidationRetryHeader = "x-validation-retry-count"
+
+// Publisher publishes messages to the broker; *amqp.Channel satisfies it
+type Publisher interface {
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// requeueOrDeadLetter settles a message the API didn't accept. The message is
+// republished to the back of the queue with an incremented retry counter until
+// it has been retried MaxRetries times, then it is dead-lettered.
+func (c *Consumer) requeueOrDeadLetter(delivery amqp.Delivery) string {
+	retries := headerInt(delivery.Headers, RetryCountHeader)
+	if retries >= c.config.MaxRetries {
+		c.logger.Warn("Message exceeded max retries, dead-lettering", map[string]interface{}{
+			"delivery_tag": delivery.DeliveryTag,
+			"retries":      retries,
+			"max_retries":  c.config.MaxRetries,
+		})
+		return c.deadLetter(delivery, DLQReasonMaxRetries, "")
+	}
+
+	headers := copyTable(delivery.Headers)
+	headers[RetryCountHeader] = int32(retries + 1)
+
+	err := c.publish("", c.config.QueueName, republishing(delivery, headers))
+	if err != nil {
+		c.logger.Error("Failed to republish message for retry", map[string]interface{}{
+			"delivery_tag": delivery.DeliveryTag,
+			"error":        err.Error(),
+		})
+		delivery.Nack(false, true)
+		return outcomeNackRequeue
+	}
+
+	delivery.Ack(false)
+	return outcomeRepublish
+}
+
+// requeueAfterDelay nacks a message with requeue after holding it for
+// RequeueDelay plus up to 50% jitter, so it doesn't immediately hit a down
+// API again. Shutdown cuts the wait short.
+func (c *Consumer) requeueAfterDelay(delivery amqp.Delivery) {
+	if delay := c.config.RequeueDelay; delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-c.stopped:
+			timer.Stop()
+		}
+	}
+	delivery.Nack(false, true)
+}
+
+// requeueLater nacks delivery with requeue after delay without holding up
+// the delivery loop, which goes on to the next message. Prefetch still
+// bounds how many wait at once. Shutdown cuts the wait short.
+func (c *Consumer) requeueLater(delivery amqp.Delivery, delay time.Duration) {
+	atomic.AddInt64(&c.deferred, 1)
+	go func() {
+		defer atomic.AddInt64(&c.deferred, -1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-c.stopped:
+			timer.Stop()
+		}
+		delivery.Nack(false, true)
+	}()
+}
+
+// requeueInvalid gives a message that failed validation one more delivery,
+// republishing it with ValidationRetryHeader set. It reports false, without
+// settling the message, if the message was already requeued once.
+func (c *Consumer) requeueInvalid(delivery amqp.Delivery) (string, bool) {
+	retries := headerInt(delivery.Headers, ValidationRetryHeader)
+	if retries >= 1 {
+		return "", false
+	}
+
+	headers := copyTable(delivery.Headers)
+	headers[ValidationRetryHeader] = int32(retries + 1)
+
+	if err := c.publish("", c.config.QueueName, republishing(delivery, headers)); err != nil {
+		c.logger.Error("Failed to republish invalid message for retry", map[string]interface{}{
+			"delivery_tag": delivery.DeliveryTag,
+			"error":        err.Error(),
+		})
+		delivery.Nack(false, true)
+		return outcomeNackRequeue, true
+	}
+
+	delivery.Ack(false)
+	return outcomeRepublish, true
+}
+
+// deadLetter moves a message to the configured dead-letter queue, tagged with
+// the reason and, for validation failures, the failing field. Without a
+// queue it is rejected without requeue so the broker's dead-letter exchange
+// (if any) takes it.
+func (c *Consumer) deadLetter(delivery amqp.Delivery, reason, field string) string {
+	if c.config.DeadLetterQueue == "" {
+		delivery.Nack(false, false)
+		return outcomeDeadLetter
+	}
+
+	err := c.publishFailed(delivery, c.config.DeadLetterQueue, reason, field)
+	if err != nil {
+		c.logger.Error("Failed to publish message to dead-letter queue", map[string]interface{}{
+			"delivery_tag": delivery.DeliveryTag,
+			"queue":        c.config.DeadLetterQueue,
+			"error":        err.Error(),
+		})
+		delivery.Nack(false, true)
+		return outcomeNackRequeue
+	}
+
+	delivery.Ack(false)
+	return outcomeDeadLetter
+}
+
+// publishFailed publishes a copy of a delivery to queue with x-dlq-* headers
+// saying why it failed
+func (c *Consumer) publishFailed(delivery amqp.Delivery, queue, reason, field string) error {
+	headers := copyTable(delivery.Headers)
+	headers[DLQReasonHeader] = reason
+	if field != "" {
+		headers[DLQFieldHeader] = field
+	}
+	headers[DLQOriginalQueueHeader] = c.config.QueueName
+	headers[DLQFailedAtHeader] = time.Now().UTC().Format(time.RFC3339)
+
+	return c.publish("", queue, republishing(delivery, headers))
+}
+
+// republishing copies a delivery's body and properties into a new publishing
+func republishing(delivery amqp.Delivery, headers amqp.Table) amqp.Publishing {
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		DeliveryMode:    amqp.Persistent,
+		Priority:        delivery.Priority,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		Expiration:      delivery.Expiration,
+		MessageId:       delivery.MessageId,
+		Timestamp:       delivery.Timestamp,
+		Type:            delivery.Type,
+		AppId:           delivery.AppId,
+		Body:            delivery.Body,
+	}
+}
+
+// copyTable returns a shallow copy of an AMQP header table
+func copyTable(table amqp.Table) amqp.Table {
+	copied := make(amqp.Table, len(table)+1)
+	for key, value := range table {
+		copied[key] = value
+	}
+	return copied
+}
+
+// headerInt reads an integer header of any AMQP integer type, or 0
+func headerInt(table amqp.Table, key string) int {
+	switch v := table[key].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package consumer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// bodyHashLength is how many hex characters of the SHA-256 are logged; enough
+// to match a producer's report to a rejection without logging the payload
+const bodyHashLength = 12
+
+// bodyHash returns a short, stable hash of a raw message body
+func bodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])[:bodyHashLength]
+}
+
+// rejectMessage logs a "Message rejected" event for a body that failed
+// validation. The body itself is only logged when
+// LogRejectedBody is set, since payloads may contain personal data.
+func (c *Consumer) rejectMessage(body []byte, err error, context map[string]interface{}) {
+	field, code := rejectionReason(err)
+
+	event := map[string]interface{}{
+		"error":       err.Error(),
+		"field":       field,
+		"code":        code,
+		"body_sha256": bodyHash(body),
+	}
+	for key, value := range context {
+		event[key] = value
+	}
+	if c.config.LogRejectedBody {
+		event["body"] = string(body)
+	}
+
+	c.logger.Error("Message rejected", event)
+}
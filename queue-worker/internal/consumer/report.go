@@ -0,0 +1,20 @@
+package consumer
+
+import (
+	"time"
+)
+
+// LogShutdownReport logs a summary of the consumer's lifetime, a quick
+// post-mortem of each worker without scraping metrics. abandoned is the
+// number of messages still being processed when the shutdown deadline was
+// hit, zero after a clean shutdown.
+func (c *Consumer) LogShutdownReport(abandoned int) {
+	c.logger.Info("Shutdown report", map[string]interface{}{
+		"processed":          uint64(c.metrics.processed.Total()),
+		"failed":             uint64(c.metrics.failed.Total()),
+		"dead_lettered":      uint64(c.metrics.outcomes.Value(outcomeDeadLetter)),
+		"duplicates_skipped": uint64(c.metrics.duplicatesSkipped.Value()),
+		"uptime_seconds":     time.Since(c.started).Seconds(),
+		"abandoned":          abandoned,
+	})
+}
@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the shutdown report
+
+func TestLogShutdownReport_Totals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.DedupCacheSize = 10
+	log := logger.New("test")
+	cons := New(cfg, api_client.NewClient(server.URL), log)
+
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 1, createValidMessageJSON()))
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 2, createValidMessageJSON()))
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 3, []byte("invalid")))
+	cons.LogShutdownReport(2)
+
+	entry, ok := findLog(log, "Shutdown report")
+	if !ok {
+		t.Fatal("Expected a shutdown report")
+	}
+	if entry.Level != logger.INFO {
+		t.Errorf("Expected INFO level, got %s", entry.Level)
+	}
+	expected := map[string]interface{}{
+		"processed":          uint64(1),
+		"failed":             uint64(0),
+		"dead_lettered":      uint64(0),
+		"duplicates_skipped": uint64(1),
+		"abandoned":          2,
+	}
+	for key, value := range expected {
+		if entry.Context[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, entry.Context[key])
+		}
+	}
+	if _, ok := entry.Context["uptime_seconds"]; !ok {
+		t.Error("Expected uptime_seconds in the report")
+	}
+}
@@ -0,0 +1,87 @@
+package consumer
+
+import (
+	"time"
+
+	"queue-worker/internal/api_client"
+)
+
+// RetryPolicy decides whether a message is sent to the API again after an
+// attempt, and how long to wait first
+type RetryPolicy interface {
+	// ShouldRetry is called with the response to attempt (starting at 1) and
+	// returns whether to make another attempt and the delay before it
+	ShouldRetry(resp *api_client.Response, attempt int) (bool, time.Duration)
+}
+
+// MaxRetryAfter caps the wait a Retry-After header can ask for, since the
+// delivery loop waits out each retry
+const MaxRetryAfter = 30 * time.Second
+
+// DefaultRetryPolicy retries responses Statuses classifies as retryable
+// (connection errors, 5xx and 429 by default) up to MaxAttempts in total,
+// waiting Delay between attempts, or the response's Retry-After up to
+// MaxRetryAfter when it has one. Other 4xx responses won't succeed on retry,
+// so they end the attempts immediately. A MaxAttempts below 1 allows just
+// the first attempt.
+type DefaultRetryPolicy struct {
+	MaxAttempts int
+	Delay       time.Duration
+	Statuses    api_client.StatusPolicy
+}
+
+// ShouldRetry implements RetryPolicy
+func (p DefaultRetryPolicy) ShouldRetry(resp *api_client.Response, attempt int) (bool, time.Duration) {
+	if attempt >= p.MaxAttempts || p.Statuses.Classify(resp) != api_client.StatusRetryable {
+		return false, 0
+	}
+	if delay, ok := resp.RetryAfter(); ok {
+		if delay > MaxRetryAfter {
+			delay = MaxRetryAfter
+		}
+		return true, delay
+	}
+	return true, p.Delay
+}
+
+// SetRetryPolicy replaces the retry policy built from RetryAttempts and
+// RetryDelay
+func (c *Consumer) SetRetryPolicy(policy RetryPolicy) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.retryPolicy = policy
+	c.customRetry = true
+}
+
+// SetRetrySettings changes RetryAttempts and RetryDelay while the consumer
+// runs, such as on a config reload, rebuilding the default retry policy
+// from them. A policy set with SetRetryPolicy is kept.
+func (c *Consumer) SetRetrySettings(attempts int, delay time.Duration) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.config.RetryAttempts = attempts
+	c.config.RetryDelay = delay
+	if !c.customRetry {
+		c.retryPolicy = DefaultRetryPolicy{MaxAttempts: attempts, Delay: delay, Statuses: c.statuses}
+	}
+}
+
+// RetrySettings returns the current RetryAttempts and RetryDelay
+func (c *Consumer) RetrySettings() (attempts int, delay time.Duration) {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.config.RetryAttempts, c.config.RetryDelay
+}
+
+// retryDelay returns the current RetryDelay
+func (c *Consumer) retryDelay() time.Duration {
+	_, delay := c.RetrySettings()
+	return delay
+}
+
+// currentRetryPolicy returns the retry policy in effect
+func (c *Consumer) currentRetryPolicy() RetryPolicy {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.retryPolicy
+}
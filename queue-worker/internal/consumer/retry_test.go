@@ -0,0 +1,233 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the retry policy
+
+func TestDefaultRetryPolicy_ShouldRetry(t *testing.T) {
+	policy := DefaultRetryPolicy{MaxAttempts: 3, Delay: 2 * time.Second}
+
+	testCases := []struct {
+		name    string
+		resp    *api_client.Response
+		attempt int
+		retry   bool
+	}{
+		{"success", &api_client.Response{StatusCode: 201}, 1, false},
+		{"client error", &api_client.Response{StatusCode: 400}, 1, false},
+		{"too many requests", &api_client.Response{StatusCode: 429}, 1, true},
+		{"server error", &api_client.Response{StatusCode: 503}, 2, true},
+		{"connection error", &api_client.Response{Error: errors.New("connection refused")}, 1, true},
+		{"attempts exhausted", &api_client.Response{StatusCode: 503}, 3, false},
+		{"too many requests exhausted", &api_client.Response{StatusCode: 429}, 3, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			retry, delay := policy.ShouldRetry(tc.resp, tc.attempt)
+			if retry != tc.retry {
+				t.Errorf("Expected retry=%v, got %v", tc.retry, retry)
+			}
+			if retry && delay != 2*time.Second {
+				t.Errorf("Expected delay 2s, got %v", delay)
+			}
+		})
+	}
+}
+
+func TestDefaultRetryPolicy_HonorsRetryAfter(t *testing.T) {
+	policy := DefaultRetryPolicy{MaxAttempts: 3, Delay: 2 * time.Second}
+
+	testCases := []struct {
+		name       string
+		retryAfter string
+		delay      time.Duration
+	}{
+		{"seconds", "5", 5 * time.Second},
+		{"zero", "0", 0},
+		{"capped", "3600", MaxRetryAfter},
+		{"date in the past", "Mon, 02 Jan 2006 15:04:05 GMT", 0},
+		{"malformed", "soon", 2 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &api_client.Response{StatusCode: 429, Headers: http.Header{"Retry-After": []string{tc.retryAfter}}}
+			retry, delay := policy.ShouldRetry(resp, 1)
+			if !retry || delay != tc.delay {
+				t.Errorf("Expected retry after %v, got retry=%v delay=%v", tc.delay, retry, delay)
+			}
+		})
+	}
+}
+
+// fixedRetries retries every failure a fixed number of times without waiting
+type fixedRetries int
+
+func (n fixedRetries) ShouldRetry(resp *api_client.Response, attempt int) (bool, time.Duration) {
+	return !resp.IsSuccess() && attempt <= int(n), 0
+}
+
+func TestSendWithRetry_UsesRetryPolicy(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	cons := New(createTestConfig(server.URL), api_client.NewClient(server.URL), logger.New("test"))
+	cons.SetRetryPolicy(fixedRetries(2))
+
+	validated, apiSuccess := cons.ProcessSingleMessage(createValidMessageJSON())
+	if !validated || apiSuccess {
+		t.Errorf("Expected validated but unsuccessful message, got validated=%v apiSuccess=%v", validated, apiSuccess)
+	}
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("Expected policy to allow 3 attempts for a 400, got %d", got)
+	}
+}
+
+func TestSendWithRetry_ZeroRetryAttemptsMakesOneAttempt(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.RetryAttempts = 0
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+
+	if _, apiSuccess := cons.ProcessSingleMessage(createValidMessageJSON()); apiSuccess {
+		t.Error("Expected the 503 to fail the message")
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected exactly 1 request with RetryAttempts=0, got %d", got)
+	}
+}
+
+func TestSendWithRetry_RetriesTooManyRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.RetryDelay = time.Millisecond
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+
+	if _, apiSuccess := cons.ProcessSingleMessage(createValidMessageJSON()); !apiSuccess {
+		t.Error("Expected message to succeed after a 429")
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestProcessMessage_StatusPolicyTreats409AsSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.APIStatusPolicy = map[string]string{"409": api_client.StatusSuccess}
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.acked) != 1 || len(ack.nacked) != 0 {
+		t.Errorf("Expected 409 acked as already stored, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+}
+
+func TestProcessMessage_StatusPolicyTreats418AsTerminal(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.DeadLetterQueue = "weather-dlq"
+	cfg.APIStatusPolicy = map[string]string{"418": api_client.StatusTerminal}
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected a single attempt for a terminal status, got %d", got)
+	}
+	if len(pub.published) != 1 || pub.published[0].msg.Headers[DLQReasonHeader] != DLQReasonClientError {
+		t.Errorf("Expected message dead-lettered as a client error, got %+v", pub.published)
+	}
+}
+
+func TestProcessMessage_StatusPolicyTreatsServerErrorAsTerminal(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotImplemented)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cfg.APIStatusPolicy = map[string]string{"501": api_client.StatusTerminal}
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+
+	result := cons.handle(context.Background(), &Message{Body: createValidMessageJSON()})
+
+	if !result.Rejected || calls.Load() != 1 {
+		t.Errorf("Expected 501 rejected after one attempt, got rejected=%v after %d calls", result.Rejected, calls.Load())
+	}
+}
+
+func TestSetRetrySettings_RebuildsDefaultPolicy(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+
+	cons.SetRetrySettings(5, time.Second)
+
+	policy, ok := cons.currentRetryPolicy().(DefaultRetryPolicy)
+	if !ok || policy.MaxAttempts != 5 || policy.Delay != time.Second {
+		t.Errorf("Expected default policy with 5 attempts 1s apart, got %+v", cons.currentRetryPolicy())
+	}
+}
+
+func TestSetRetrySettings_KeepsCustomPolicy(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+	cons.SetRetryPolicy(fixedRetries(2))
+
+	cons.SetRetrySettings(5, time.Second)
+
+	if _, ok := cons.currentRetryPolicy().(fixedRetries); !ok {
+		t.Errorf("Expected the custom policy to be kept, got %T", cons.currentRetryPolicy())
+	}
+	if attempts, delay := cons.RetrySettings(); attempts != 5 || delay != time.Second {
+		t.Errorf("Expected settings updated, got %d and %v", attempts, delay)
+	}
+}
@@ -0,0 +1,16 @@
+package consumer
+
+import (
+	"queue-worker/internal/schema"
+	"queue-worker/internal/validator"
+)
+
+// SetSchemaProvider also checks messages against the JSON Schema registered
+// for their source and schema version, as Config.SchemaMode says. Messages
+// rejected because the schema couldn't be fetched are requeued rather than
+// dropped.
+func (c *Consumer) SetSchemaProvider(provider schema.Provider) {
+	opts := c.config.ValidatorOptions()
+	opts.SchemaProvider = provider
+	c.validator = validator.New(opts)
+}
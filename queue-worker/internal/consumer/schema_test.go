@@ -0,0 +1,80 @@
+package consumer
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+	"queue-worker/internal/schema"
+)
+
+// Unit tests for validating against a schema registry
+
+// unavailableSchemas fails every lookup, as a registry outage would
+type unavailableSchemas struct{}
+
+func (unavailableSchemas) Schema(source string, version int) (*schema.Schema, error) {
+	return nil, errors.New("registry down")
+}
+
+func TestProcessMessage_RequeuesWhenSchemaUnavailable(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.SchemaFailOpen = false
+	cfg.RetryDelay = time.Hour
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+	cons.SetSchemaProvider(unavailableSchemas{})
+
+	ack := &fakeAcknowledger{}
+	started := time.Now()
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("Expected the delivery loop not to wait out the retry delay, took %v", elapsed)
+	}
+	if got := atomic.LoadInt64(&cons.deferred); got != 1 {
+		t.Fatalf("Expected the requeue deferred, got %d pending", got)
+	}
+
+	cons.stop() // shutdown cuts the wait short
+	for atomic.LoadInt64(&cons.deferred) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if len(ack.nacked) != 1 || !ack.requeued[0] {
+		t.Errorf("Expected nack with requeue, got nacked=%v requeued=%v", ack.nacked, ack.requeued)
+	}
+	if got := cons.metrics.outcomes.Value(outcomeNackRequeue); got != 1 {
+		t.Errorf("Expected one nack_requeue outcome, got %v", got)
+	}
+}
+
+func TestAck_NotBatchedWhileRequeueDeferred(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.BatchAckSize = 10
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+	atomic.StoreInt64(&cons.deferred, 1)
+
+	ack := &fakeAcknowledger{}
+	if batched := cons.ack(createDelivery(ack, 2, nil)); batched {
+		t.Error("Expected a single ack while a lower delivery awaits its requeue")
+	}
+	if len(ack.acked) != 1 || ack.multiple[0] {
+		t.Errorf("Expected one single ack, got acked=%v multiple=%v", ack.acked, ack.multiple)
+	}
+}
+
+func TestProcessMessage_SchemaFailOpenSendsMessage(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.SchemaFailOpen = true
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL, api_client.WithStub(201, 0)), logger.New("test"))
+	cons.SetSchemaProvider(unavailableSchemas{})
+
+	ack := &fakeAcknowledger{}
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.acked) != 1 {
+		t.Errorf("Expected the message to be sent and acked, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+}
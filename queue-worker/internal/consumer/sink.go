@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"queue-worker/internal/dedup"
+	"queue-worker/internal/validator"
+)
+
+// sinkPendingCapacity bounds how many bodies accepted by the API but not yet
+// published to a required sink are remembered for their redelivery
+const sinkPendingCapacity = 10000
+
+// sinkPublisher publishes to the secondary sink on a confirm-mode channel of
+// its own, so a sink failure (a missing exchange closes the channel) never
+// disturbs consuming. A closed channel is reopened on the next publish.
+type sinkPublisher struct {
+	conn    func() *amqp.Connection
+	timeout time.Duration
+
+	mu sync.Mutex // serializes publishes and guards ch
+	ch *amqp.Channel
+}
+
+// PublishWithContext implements Publisher
+func (p *sinkPublisher) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ch == nil || p.ch.IsClosed() {
+		conn := p.conn()
+		if conn == nil {
+			return errNoChannel
+		}
+		ch, err := conn.Channel()
+		if err != nil {
+			return err
+		}
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return err
+		}
+		p.ch = ch
+	}
+
+	confirming := confirmingPublisher{ch: p.ch, timeout: p.timeout}
+	return confirming.PublishWithContext(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+// currentConn returns the RabbitMQ connection, nil while disconnected
+func (c *Consumer) currentConn() *amqp.Connection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+// emitToSink publishes a message the API accepted to the secondary sink, if
+// one is configured, as the JSON the API received. It reports false when the
+// publish failed and SinkRequired says the message must not be acked; the
+// body is then remembered so its redelivery only retries the publish.
+func (c *Consumer) emitToSink(msg *Message, parsed *validator.WeatherMessage) bool {
+	if c.sink == nil {
+		return true
+	}
+	deliveryTag := msg.DeliveryTag
+
+	body, err := json.Marshal(parsed)
+	if err == nil {
+		err = c.sink.PublishWithContext(context.Background(), c.config.SinkExchange, c.config.SinkRoutingKey, false, false, amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+			Body:         body,
+		})
+	}
+	if err != nil {
+		c.metrics.sinkFailed.Inc()
+		c.logger.Error("Failed to publish message to secondary sink", map[string]interface{}{
+			"delivery_tag": deliveryTag,
+			"exchange":     c.config.SinkExchange,
+			"routing_key":  c.config.SinkRoutingKey,
+			"required":     c.config.SinkRequired,
+			"error":        err.Error(),
+		})
+		if c.sinkPending == nil {
+			return true
+		}
+		c.sinkPending.Add(dedup.Hash(msg.Body))
+		return false
+	}
+
+	c.metrics.sinkPublished.Inc()
+	if c.sinkPending != nil {
+		c.sinkPending.Remove(dedup.Hash(msg.Body))
+	}
+	return true
+}
+
+// sinkPendingFor reports whether body was accepted by the API but its
+// required sink publish failed
+func (c *Consumer) sinkPendingFor(body []byte) bool {
+	return c.sinkPending != nil && c.sinkPending.Seen(dedup.Hash(body))
+}
+
+// retrySink handles the redelivery of a message the API already accepted by
+// publishing it to the sink again, without calling the API
+func (c *Consumer) retrySink(msg *Message) *Result {
+	body, err := c.transformBody(msg.Body)
+	if err != nil {
+		return &Result{ValidationErr: err}
+	}
+	parsed, err := c.validator.ValidateMessage(body)
+	if err != nil {
+		return &Result{ValidationErr: err}
+	}
+
+	result := &Result{Parsed: parsed, Success: true, SinkRetry: true}
+	result.SinkFailed = !c.emitToSink(msg, parsed)
+	return result
+}
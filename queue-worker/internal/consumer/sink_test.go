@@ -0,0 +1,134 @@
+package consumer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the secondary sink
+
+// newSinkTestConsumer returns a consumer whose sink is a fakePublisher and
+// whose API accepts everything, counting requests in apiCalls
+func newSinkTestConsumer(t *testing.T, required bool) (cons *Consumer, sink *fakePublisher, log *logger.Logger, apiCalls *int32) {
+	t.Helper()
+	apiCalls = new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(apiCalls, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createTestConfig(server.URL)
+	cfg.SinkExchange = "weather-analytics"
+	cfg.SinkRoutingKey = "weather.accepted"
+	cfg.SinkRequired = required
+	log = logger.New("test")
+	cons = New(cfg, api_client.NewClient(server.URL), log)
+	sink = &fakePublisher{}
+	cons.sink = sink
+	return cons, sink, log, apiCalls
+}
+
+func TestProcessMessage_PublishesToSink(t *testing.T) {
+	cons, sink, _, _ := newSinkTestConsumer(t, false)
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.acked) != 1 {
+		t.Fatalf("Expected message acked, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+	if len(sink.published) != 1 {
+		t.Fatalf("Expected one sink publish, got %d", len(sink.published))
+	}
+	published := sink.published[0]
+	if published.exchange != "weather-analytics" || published.key != "weather.accepted" {
+		t.Errorf("Expected weather-analytics/weather.accepted, got %s/%s", published.exchange, published.key)
+	}
+	var body struct {
+		Location struct {
+			City string `json:"city"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(published.msg.Body, &body); err != nil || body.Location.City != "São Paulo" {
+		t.Errorf("Expected the accepted message as JSON, got %s (%v)", published.msg.Body, err)
+	}
+	if got := cons.metrics.sinkPublished.Value(); got != 1 {
+		t.Errorf("Expected sink published counter 1, got %v", got)
+	}
+}
+
+func TestProcessMessage_SinkFailureStillAcks(t *testing.T) {
+	cons, sink, log, _ := newSinkTestConsumer(t, false)
+	sink.err = errPublishNacked
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.acked) != 1 {
+		t.Errorf("Expected message acked despite the sink failure, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+	entry, ok := findLog(log, "Failed to publish message to secondary sink")
+	if !ok || entry.Context["exchange"] != "weather-analytics" {
+		t.Errorf("Expected sink failure logged, got %+v", entry)
+	}
+	if got := cons.metrics.sinkFailed.Value(); got != 1 {
+		t.Errorf("Expected sink failed counter 1, got %v", got)
+	}
+}
+
+func TestProcessMessage_RequiredSinkFailureRequeues(t *testing.T) {
+	cons, sink, log, _ := newSinkTestConsumer(t, true)
+	sink.err = errPublishNacked
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.nacked) != 1 || !ack.requeued[0] {
+		t.Errorf("Expected message requeued, got acked=%v nacked=%v requeued=%v", ack.acked, ack.nacked, ack.requeued)
+	}
+	if log.HasLogWithMessage("Failed to send message to API after retries") {
+		t.Error("Expected no API failure logged for a message the API accepted")
+	}
+	if !log.HasLogWithMessage("Message accepted by API but not published to required sink, requeueing") {
+		t.Error("Expected the sink failure logged on its own line")
+	}
+	if got := cons.metrics.failed.Total(); got != 0 {
+		t.Errorf("Expected no API failure counted, got %v", got)
+	}
+	if got := cons.metrics.processed.Total(); got != 1 {
+		t.Errorf("Expected the API acceptance counted, got %v", got)
+	}
+}
+
+func TestProcessMessage_RequiredSinkRedeliveryOnlyRetriesSink(t *testing.T) {
+	cons, sink, _, apiCalls := newSinkTestConsumer(t, true)
+	sink.err = errPublishNacked
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 1, createValidMessageJSON()))
+
+	sink.err = nil
+	ack := &fakeAcknowledger{}
+	cons.processMessage(createDelivery(ack, 2, createValidMessageJSON()))
+
+	if got := atomic.LoadInt32(apiCalls); got != 1 {
+		t.Errorf("Expected the API called once across both deliveries, got %d", got)
+	}
+	if len(sink.published) != 1 {
+		t.Errorf("Expected the redelivery published to the sink, got %d publishes", len(sink.published))
+	}
+	if len(ack.acked) != 1 {
+		t.Errorf("Expected the redelivery acked, got acked=%v nacked=%v", ack.acked, ack.nacked)
+	}
+
+	// Now published, the same body is a new message again
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 3, createValidMessageJSON()))
+	if got := atomic.LoadInt32(apiCalls); got != 2 {
+		t.Errorf("Expected a later message with the same body sent to the API, got %d calls", got)
+	}
+}
@@ -0,0 +1,31 @@
+package consumer
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// oversize records the delivery's raw body size and reports whether it is
+// over MaxMessageBytes, logging it if so
+func (c *Consumer) oversize(delivery amqp.Delivery) bool {
+	size := len(delivery.Body)
+	c.metrics.bodyBytes.Observe(float64(size))
+	if c.config.MaxMessageBytes <= 0 || size <= c.config.MaxMessageBytes {
+		return false
+	}
+
+	c.logger.Warn("Message exceeds size limit", map[string]interface{}{
+		"delivery_tag": delivery.DeliveryTag,
+		"size_bytes":   size,
+		"limit_bytes":  c.config.MaxMessageBytes,
+	})
+	return true
+}
+
+// decodedLimit is the largest body decodeBody may decompress to:
+// MaxMessageBytes, or defaultMaxDecodedBytes when that is unset
+func (c *Consumer) decodedLimit() int {
+	if c.config.MaxMessageBytes > 0 {
+		return c.config.MaxMessageBytes
+	}
+	return defaultMaxDecodedBytes
+}
@@ -0,0 +1,80 @@
+package consumer
+
+import (
+	"bytes"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for body size metrics and limits
+
+func TestProcessMessage_RecordsBodySize(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 1, []byte("invalid")))
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 2, []byte("{}")))
+
+	if got := cons.metrics.bodyBytes.Count(); got != 2 {
+		t.Errorf("Expected 2 body sizes observed, got %d", got)
+	}
+}
+
+func TestProcessMessage_DeadLettersGzipBombAsOversize(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.MaxMessageBytes = 4096
+	cfg.DeadLetterQueue = "weather-dlq"
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	ack := &fakeAcknowledger{}
+
+	// Compresses to well under the limit but decompresses far past it
+	compressed := gzipBody(t, bytes.Repeat([]byte(" "), 1<<20))
+	if len(compressed) > cfg.MaxMessageBytes {
+		t.Fatalf("Expected a compressed body under the limit, got %d bytes", len(compressed))
+	}
+	delivery := createDelivery(ack, 1, compressed)
+	delivery.ContentEncoding = "gzip"
+	cons.processMessage(delivery)
+
+	if len(pub.published) != 1 || pub.published[0].msg.Headers[DLQReasonHeader] != DLQReasonOversize {
+		t.Errorf("Expected message dead-lettered as oversize, got %+v", pub.published)
+	}
+}
+
+func TestDecodeBody_LimitsDecompressedSize(t *testing.T) {
+	delivery := createDelivery(&fakeAcknowledger{}, 1, gzipBody(t, []byte("0123456789")))
+
+	if body, err := decodeBody(delivery, 10); err != nil || string(body) != "0123456789" {
+		t.Errorf("Expected a body at the limit to decode, got %q (%v)", body, err)
+	}
+	if _, err := decodeBody(delivery, 9); err != errDecodedTooLarge {
+		t.Errorf("Expected errDecodedTooLarge past the limit, got %v", err)
+	}
+}
+
+func TestProcessMessage_DeadLettersOversizeMessage(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cfg.MaxMessageBytes = 64
+	cfg.DeadLetterQueue = "weather-dlq"
+	log := logger.New("test")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+	pub := &fakePublisher{}
+	cons.publisher = pub
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, bytes.Repeat([]byte("x"), 65)))
+
+	if len(pub.published) != 1 || pub.published[0].msg.Headers[DLQReasonHeader] != DLQReasonOversize {
+		t.Errorf("Expected message dead-lettered as oversize, got %+v", pub.published)
+	}
+	if len(ack.acked) != 1 {
+		t.Errorf("Expected delivery acked after dead-lettering, got %v", ack.acked)
+	}
+	if entry, ok := findLog(log, "Message exceeds size limit"); !ok || entry.Context["size_bytes"] != 65 {
+		t.Errorf("Expected oversize warning with the body size, got %+v", entry)
+	}
+}
@@ -0,0 +1,72 @@
+package consumer
+
+import (
+	"context"
+	"time"
+
+	"queue-worker/internal/breaker"
+	"queue-worker/internal/spool"
+	"queue-worker/internal/validator"
+)
+
+// SetSpool enables spooling: while the API circuit is open, validated messages
+// are written to s and acknowledged instead of being retried against the API
+func (c *Consumer) SetSpool(s *spool.Spool) {
+	c.spool = s
+}
+
+// trySpool appends msg to the spool if the API circuit is open. It reports
+// whether the message was spooled; the caller acknowledges the delivery.
+func (c *Consumer) trySpool(deliveryTag uint64, msg *validator.WeatherMessage) bool {
+	if c.spool == nil || c.breaker.State() != breaker.Open {
+		return false
+	}
+
+	if err := c.spool.Append(msg); err != nil {
+		c.logger.Warn("Failed to spool message", map[string]interface{}{
+			"delivery_tag": deliveryTag,
+			"error":        err.Error(),
+		})
+		return false
+	}
+
+	c.logger.Info("Message spooled while API circuit is open", map[string]interface{}{
+		"delivery_tag": deliveryTag,
+		"spool_bytes":  c.spool.Size(),
+	})
+	return true
+}
+
+// flushSpool periodically re-submits spooled messages once the API circuit is
+// no longer open, until the delivery loop exits
+func (c *Consumer) flushSpool() {
+	ticker := time.NewTicker(c.config.SpoolFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		if c.spool.Size() == 0 || c.breaker.State() == breaker.Open {
+			continue
+		}
+
+		sent, err := c.spool.Drain(func(msg *validator.WeatherMessage) bool {
+			return c.sendWithRetry(context.Background(), msg, nil).success
+		})
+		if err != nil {
+			c.logger.Error("Failed to drain spool", map[string]interface{}{
+				"error": err.Error(),
+				"sent":  sent,
+			})
+		} else if sent > 0 {
+			c.logger.Info("Re-submitted spooled messages", map[string]interface{}{
+				"sent":        sent,
+				"spool_bytes": c.spool.Size(),
+			})
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package consumer
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// StatsHandler serves Stats as JSON, for tooling that wants a status
+// snapshot without scraping Prometheus metrics
+func (c *Consumer) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Stats())
+	})
+}
@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+)
+
+// Unit tests for the JSON stats snapshot
+
+func TestStatsHandler_ServesSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	cons.queueDepth = func() (int, error) { return 7, nil }
+	cons.processMessage(createDelivery(&fakeAcknowledger{}, 1, createValidMessageJSON()))
+
+	rec := httptest.NewRecorder()
+	cons.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+	var stats Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if stats.Processed != 1 || stats.Failed != 0 {
+		t.Errorf("Expected 1 processed and 0 failed, got %+v", stats)
+	}
+	if stats.LastSuccess == nil || stats.LastFailure != nil {
+		t.Errorf("Expected only a last success time, got success=%v failure=%v", stats.LastSuccess, stats.LastFailure)
+	}
+	if stats.Circuit != "closed" || stats.Connected {
+		t.Errorf("Expected closed circuit and no connection, got %q connected=%v", stats.Circuit, stats.Connected)
+	}
+	if stats.Backlog == nil || *stats.Backlog != 7 {
+		t.Errorf("Expected backlog 7, got %v", stats.Backlog)
+	}
+}
+
+func TestStats_OmitsBacklogWhenQueueCannotBeInspected(t *testing.T) {
+	cfg := createTestConfig("http://example.com")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), logger.New("test"))
+
+	if stats := cons.Stats(); stats.Backlog != nil {
+		t.Errorf("Expected no backlog while disconnected, got %d", *stats.Backlog)
+	}
+}
@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"queue-worker/internal/transform"
+)
+
+// transformError reports a body the message transformer failed on
+type transformError struct {
+	err error
+}
+
+func (e transformError) Error() string {
+	return "transform failed: " + e.err.Error()
+}
+
+func (e transformError) Unwrap() error {
+	return e.err
+}
+
+// SetTransformer sets the transformer applied to message bodies before
+// validation (transform.Identity by default). A body it fails on is rejected
+// like one that fails validation.
+func (c *Consumer) SetTransformer(transformer transform.Transformer) {
+	c.transformer = transformer
+}
+
+// transformBody runs the message transformer over a body
+func (c *Consumer) transformBody(body []byte) ([]byte, error) {
+	transformed, err := c.transformer.Transform(body)
+	if err != nil {
+		return nil, transformError{err: err}
+	}
+	return transformed, nil
+}
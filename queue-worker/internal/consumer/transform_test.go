@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"queue-worker/internal/api_client"
+	"queue-worker/internal/logger"
+	"queue-worker/internal/transform"
+)
+
+// Unit tests for transforming bodies before validation
+
+func TestProcessSingleMessage_TransformsBeforeValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig(server.URL)
+	cons := New(cfg, api_client.NewClient(server.URL), logger.New("test"))
+	cons.SetTransformer(transform.RenameFields{"weather.temp": "weather.temperature"})
+
+	body := []byte(`{"timestamp":"2025-12-03T14:30:00Z","location":{"city":"Recife","latitude":-8.05,"longitude":-34.9},` +
+		`"weather":{"temp":29,"humidity":80,"windSpeed":4,"condition":"sunny","rainProbability":10},"source":"openweather"}`)
+
+	if validated, success := cons.ProcessSingleMessage(body); !validated || !success {
+		t.Errorf("Expected renamed message to validate and send, got validated=%v success=%v", validated, success)
+	}
+}
+
+func TestProcessMessage_RejectsBodyTransformerFailsOn(t *testing.T) {
+	log := logger.New("test")
+	cfg := createTestConfig("http://example.com")
+	cons := New(cfg, api_client.NewClient(cfg.APIServiceURL), log)
+	cons.SetTransformer(transform.Func(func(raw []byte) ([]byte, error) {
+		return nil, errors.New("unexpected layout")
+	}))
+	ack := &fakeAcknowledger{}
+
+	cons.processMessage(createDelivery(ack, 1, createValidMessageJSON()))
+
+	if len(ack.nacked) != 1 || ack.requeued[0] {
+		t.Errorf("Expected nack without requeue, got nacked=%v requeued=%v", ack.nacked, ack.requeued)
+	}
+	if got := cons.metrics.validationFailures.Value("body", "transform_failed"); got != 1 {
+		t.Errorf("Expected 1 transform_failed validation failure, got %v", got)
+	}
+	if entry, ok := findLog(log, "Message rejected"); !ok || entry.Context["code"] != "transform_failed" {
+		t.Errorf("Expected rejection log with transform_failed code, got %+v", entry)
+	}
+}
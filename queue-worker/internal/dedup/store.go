@@ -0,0 +1,80 @@
+package dedup
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// Hash returns the hex SHA-256 of a message body, the key the store tracks
+func Hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store remembers the hashes of the most recently processed messages,
+// evicting the least recently seen once it holds capacity of them
+type Store struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // most recently seen first
+	entries map[string]*list.Element
+}
+
+// New creates a store holding up to capacity hashes
+func New(capacity int) *Store {
+	return &Store{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Seen reports whether hash is in the store, marking it recently seen
+func (s *Store) Seen(hash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[hash]
+	if ok {
+		s.order.MoveToFront(elem)
+	}
+	return ok
+}
+
+// Add records hash, evicting the least recently seen hash if full
+func (s *Store) Add(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[hash]; ok {
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.entries[hash] = s.order.PushFront(hash)
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(string))
+	}
+}
+
+// Remove forgets hash, if held
+func (s *Store) Remove(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[hash]; ok {
+		s.order.Remove(elem)
+		delete(s.entries, hash)
+	}
+}
+
+// Len returns the number of hashes held
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
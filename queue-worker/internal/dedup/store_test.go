@@ -0,0 +1,57 @@
+package dedup
+
+import "testing"
+
+// Unit tests for the dedup store
+
+func TestStore_SeenAfterAdd(t *testing.T) {
+	store := New(2)
+	hash := Hash([]byte(`{"a":1}`))
+
+	if store.Seen(hash) {
+		t.Errorf("Expected unseen hash before Add")
+	}
+	store.Add(hash)
+	if !store.Seen(hash) {
+		t.Errorf("Expected hash to be seen after Add")
+	}
+}
+
+func TestStore_Remove(t *testing.T) {
+	store := New(2)
+	hash := Hash([]byte(`{"a":1}`))
+	store.Add(hash)
+
+	store.Remove(hash)
+	store.Remove(hash) // removing an absent hash is a no-op
+	if store.Seen(hash) || store.Len() != 0 {
+		t.Errorf("Expected hash forgotten after Remove, got len %d", store.Len())
+	}
+}
+
+func TestStore_EvictsLeastRecentlySeen(t *testing.T) {
+	store := New(2)
+	store.Add("a")
+	store.Add("b")
+	store.Seen("a") // a is now more recent than b
+	store.Add("c")
+
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 hashes, got %d", store.Len())
+	}
+	if store.Seen("b") {
+		t.Errorf("Expected b to be evicted")
+	}
+	if !store.Seen("a") || !store.Seen("c") {
+		t.Errorf("Expected a and c to be kept")
+	}
+}
+
+func TestHash_DiffersByBody(t *testing.T) {
+	if Hash([]byte("one")) == Hash([]byte("two")) {
+		t.Errorf("Expected different bodies to hash differently")
+	}
+	if Hash([]byte("one")) != Hash([]byte("one")) {
+		t.Errorf("Expected equal bodies to hash the same")
+	}
+}
@@ -0,0 +1,67 @@
+package enrich
+
+// RegionLookup resolves coordinates to the name of the region containing
+// them. An empty name with a nil error means no region matched.
+type RegionLookup interface {
+	Region(latitude, longitude float64) (string, error)
+}
+
+// NoopLookup never resolves a region; it is the default
+type NoopLookup struct{}
+
+// Region implements RegionLookup
+func (NoopLookup) Region(latitude, longitude float64) (string, error) {
+	return "", nil
+}
+
+// Box is a named latitude/longitude rectangle, bounds inclusive
+type Box struct {
+	Name   string
+	MinLat float64
+	MaxLat float64
+	MinLon float64
+	MaxLon float64
+}
+
+// contains reports whether the coordinates fall inside the box
+func (b Box) contains(latitude, longitude float64) bool {
+	return latitude >= b.MinLat && latitude <= b.MaxLat &&
+		longitude >= b.MinLon && longitude <= b.MaxLon
+}
+
+// BoundingBoxes resolves the region as the first box containing the
+// coordinates, so overlapping boxes are settled by their order
+type BoundingBoxes []Box
+
+// Region implements RegionLookup
+func (boxes BoundingBoxes) Region(latitude, longitude float64) (string, error) {
+	for _, box := range boxes {
+		if box.contains(latitude, longitude) {
+			return box.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// BrazilRegions approximates Brazil's five macro-regions with rectangles.
+// The boundaries are coarse: cities near a border may resolve to the
+// neighboring region.
+var BrazilRegions = BoundingBoxes{
+	{Name: "Sul", MinLat: -33.8, MaxLat: -22.5, MinLon: -57.7, MaxLon: -48.0},
+	{Name: "Centro-Oeste", MinLat: -24.1, MaxLat: -7.3, MinLon: -61.6, MaxLon: -47.3},
+	{Name: "Sudeste", MinLat: -25.3, MaxLat: -14.2, MinLon: -53.1, MaxLon: -39.6},
+	{Name: "Norte", MinLat: -13.7, MaxLat: 5.3, MinLon: -74.0, MaxLon: -46.0},
+	{Name: "Nordeste", MinLat: -18.4, MaxLat: -1.0, MinLon: -48.8, MaxLon: -34.7},
+}
+
+// LookupByName returns the region lookup registered under name ("none" or
+// "brazil")
+func LookupByName(name string) (RegionLookup, bool) {
+	switch name {
+	case "none":
+		return NoopLookup{}, true
+	case "brazil":
+		return BrazilRegions, true
+	}
+	return nil, false
+}
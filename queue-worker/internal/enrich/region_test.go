@@ -0,0 +1,53 @@
+package enrich
+
+import "testing"
+
+// Unit tests for region lookups
+
+func TestBrazilRegions_Capitals(t *testing.T) {
+	testCases := []struct {
+		city      string
+		latitude  float64
+		longitude float64
+		region    string
+	}{
+		{"São Paulo", -23.5505, -46.6333, "Sudeste"},
+		{"Rio de Janeiro", -22.9068, -43.1729, "Sudeste"},
+		{"Porto Alegre", -30.0346, -51.2177, "Sul"},
+		{"Curitiba", -25.4284, -49.2733, "Sul"},
+		{"Brasília", -15.7939, -47.8828, "Centro-Oeste"},
+		{"Cuiabá", -15.6014, -56.0979, "Centro-Oeste"},
+		{"Salvador", -12.9714, -38.5014, "Nordeste"},
+		{"Recife", -8.0476, -34.8770, "Nordeste"},
+		{"Manaus", -3.1190, -60.0217, "Norte"},
+		{"Belém", -1.4558, -48.4902, "Norte"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.city, func(t *testing.T) {
+			region, err := BrazilRegions.Region(tc.latitude, tc.longitude)
+			if err != nil || region != tc.region {
+				t.Errorf("Expected %s, got %q (err=%v)", tc.region, region, err)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxes_NoMatch(t *testing.T) {
+	region, err := BrazilRegions.Region(48.8566, 2.3522)
+	if err != nil || region != "" {
+		t.Errorf("Expected no region outside every box, got %q (err=%v)", region, err)
+	}
+}
+
+func TestLookupByName(t *testing.T) {
+	if _, ok := LookupByName("none"); !ok {
+		t.Error("Expected none lookup to be registered")
+	}
+	if _, ok := LookupByName("brazil"); !ok {
+		t.Error("Expected brazil lookup to be registered")
+	}
+	if _, ok := LookupByName("geonames"); ok {
+		t.Error("Expected unknown lookup to be rejected")
+	}
+}
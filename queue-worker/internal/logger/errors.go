@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxErrorChainDepth caps how many wrapped errors ErrorChain lists
+const MaxErrorChainDepth = 10
+
+// ErrorChain unwraps err with errors.Unwrap into one entry per layer,
+// outermost first, for an "error_chain" log field. Each entry keeps only the
+// text its layer added, so "failed to send request: dial tcp: connection
+// refused" becomes ["failed to send request", "dial tcp", "connection
+// refused"]. Chains deeper than MaxErrorChainDepth end with the remaining
+// text unsplit.
+func ErrorChain(err error) []string {
+	var chain []string
+	for err != nil {
+		next := errors.Unwrap(err)
+		text := err.Error()
+		if next == nil || len(chain) == MaxErrorChainDepth-1 {
+			return append(chain, text)
+		}
+		chain = append(chain, strings.TrimSuffix(text, ": "+next.Error()))
+		err = next
+	}
+	return chain
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Unit tests for error chain unwrapping
+
+type opError struct {
+	op  string
+	err error
+}
+
+func (e *opError) Error() string { return e.op + ": " + e.err.Error() }
+func (e *opError) Unwrap() error { return e.err }
+
+func TestErrorChain_SplitsWrappedErrors(t *testing.T) {
+	refused := errors.New("connection refused")
+	err := fmt.Errorf("failed to send request: %w", &opError{op: "dial tcp 10.0.0.1:3000", err: refused})
+
+	want := []string{"failed to send request", "dial tcp 10.0.0.1:3000", "connection refused"}
+	if got := ErrorChain(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestErrorChain_KeepsLayerTextNotEndingInCause(t *testing.T) {
+	err := fmt.Errorf("%w (after 3 attempts)", errors.New("timeout"))
+
+	want := []string{"timeout (after 3 attempts)", "timeout"}
+	if got := ErrorChain(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestErrorChain_CapsDepth(t *testing.T) {
+	err := errors.New("root")
+	for i := 0; i < 2*MaxErrorChainDepth; i++ {
+		err = fmt.Errorf("layer %d: %w", i, err)
+	}
+
+	chain := ErrorChain(err)
+	if len(chain) != MaxErrorChainDepth {
+		t.Fatalf("Expected %d entries, got %d: %q", MaxErrorChainDepth, len(chain), chain)
+	}
+	if last := chain[len(chain)-1]; last != "layer 10: layer 9: layer 8: layer 7: layer 6: layer 5: layer 4: layer 3: layer 2: layer 1: layer 0: root" {
+		t.Errorf("Expected the rest of the chain unsplit in the last entry, got %q", last)
+	}
+}
+
+func TestErrorChain_Nil(t *testing.T) {
+	if chain := ErrorChain(nil); chain != nil {
+		t.Errorf("Expected nil for a nil error, got %q", chain)
+	}
+}
@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// EntriesHandler serves the most recent entries as a JSON array, oldest
+// first, for live debugging. The optional limit query parameter caps how
+// many are returned. Entries can carry message details, so only expose it
+// on an internal address.
+func (l *Logger) EntriesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(l.RecentEntries(limit))
+	})
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Unit tests for the recent entries HTTP handler
+
+func TestEntriesHandler_ServesRecentEntries(t *testing.T) {
+	log := New("test", WithMaxEntries(10), WithSplitStreams(&bytes.Buffer{}, &bytes.Buffer{}))
+	log.Info("Starting queue worker", nil)
+	log.Warn("API returned error status", map[string]interface{}{"status_code": 503})
+
+	rec := httptest.NewRecorder()
+	log.EntriesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs?limit=1", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected JSON 200, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var entries []LogEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Expected a JSON array, got %s", rec.Body.String())
+	}
+	if len(entries) != 1 || entries[0].Message != "API returned error status" || entries[0].Level != WARN {
+		t.Errorf("Expected only the latest entry, got %+v", entries)
+	}
+}
+
+func TestEntriesHandler_EmptyAndInvalidLimit(t *testing.T) {
+	log := New("test")
+
+	rec := httptest.NewRecorder()
+	log.EntriesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs", nil))
+	if rec.Body.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	log.EntriesHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs?limit=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"strings"
+)
+
+// levelRank orders levels from most to least verbose
+var levelRank = map[Level]int{DEBUG: 0, INFO: 1, WARN: 2, ERROR: 3}
+
+// ParseLevel parses a level name, case-insensitively
+func ParseLevel(name string) (Level, bool) {
+	level := Level(strings.ToUpper(strings.TrimSpace(name)))
+	_, ok := levelRank[level]
+	return level, ok
+}
+
+// WithLevel drops entries below level. Every level is logged by default.
+func WithLevel(level Level) Option {
+	return func(l *Logger) {
+		l.minLevel = level
+	}
+}
+
+// SetLevel changes the minimum level logged, for example on a config
+// reload. It applies to child loggers as well.
+func (l *Logger) SetLevel(level Level) {
+	l = l.base()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.minLevel = level
+}
+
+// MinLevel returns the minimum level logged
+func (l *Logger) MinLevel() Level {
+	l = l.base()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.minLevel == "" {
+		return DEBUG
+	}
+	return l.minLevel
+}
+
+// enabledLocked reports whether entries at level are logged; l.mu must be held
+func (l *Logger) enabledLocked(level Level) bool {
+	return levelRank[level] >= levelRank[l.minLevel]
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 	"time"
@@ -30,31 +31,221 @@ type LogEntry struct {
 // Logger provides structured logging functionality
 type Logger struct {
 	service string
+	root    *Logger                // set on child loggers, which write through it
+	fields  map[string]interface{} // base context added to every entry
 	mu      sync.Mutex
 	entries []LogEntry // For testing purposes
+	// maxEntries bounds entries to the most recent; zero keeps them all
+	maxEntries int
+
+	minLevel Level // entries below it are dropped; empty logs every level
+
+	dedupWindow time.Duration
+	pending     *pendingEntry
+
+	sampling *debugSampler
+
+	out    io.Writer // all entries, or DEBUG/INFO when errOut is set
+	errOut io.Writer // WARN/ERROR entries when streams are split
+
+	timeLayout string         // empty means time.RFC3339
+	timeZone   *time.Location // nil means UTC
+}
+
+// debugSampler thins DEBUG entries per message: within each window the first
+// entries are kept, then only every Nth
+type debugSampler struct {
+	first       int
+	thereafter  int
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]int
+}
+
+// pendingEntry tracks a run of identical entries being collapsed by dedup
+type pendingEntry struct {
+	level   Level
+	message string
+	repeats int
+	timer   *time.Timer
+}
+
+// Option configures optional Logger behavior
+type Option func(*Logger)
+
+// WithDedup collapses consecutive identical (level+message) entries logged
+// within window into the first line plus a single summary line carrying the
+// repeat count, emitted when the window closes or the message changes.
+// A zero window disables deduplication.
+func WithDedup(window time.Duration) Option {
+	return func(l *Logger) {
+		l.dedupWindow = window
+	}
+}
+
+// WithDebugSampling samples DEBUG entries per message text: within each
+// window the first `first` entries are logged, then every `thereafter`-th.
+// For a plain 1-in-N sample use first=0, thereafter=N. A zero window counts
+// over the logger's lifetime. INFO, WARN and ERROR entries are never sampled.
+func WithDebugSampling(first, thereafter int, window time.Duration) Option {
+	return func(l *Logger) {
+		if first <= 0 && thereafter <= 0 {
+			return
+		}
+		l.sampling = &debugSampler{
+			first:      first,
+			thereafter: thereafter,
+			window:     window,
+			counts:     make(map[string]int),
+		}
+	}
+}
+
+// WithSplitStreams writes WARN and ERROR entries to errOut and DEBUG and INFO
+// entries to out, for log shippers that separate streams. Nil writers default
+// to os.Stderr and os.Stdout. Writes to both are serialized, so lines never
+// interleave.
+func WithSplitStreams(out, errOut io.Writer) Option {
+	return func(l *Logger) {
+		if out == nil {
+			out = os.Stdout
+		}
+		if errOut == nil {
+			errOut = os.Stderr
+		}
+		l.out = out
+		l.errOut = errOut
+	}
+}
+
+// WithMaxEntries keeps only the most recent n entries in memory, for
+// GetEntries and EntriesHandler, so a long-running process doesn't grow
+// without bound. Zero or less keeps every entry.
+func WithMaxEntries(n int) Option {
+	return func(l *Logger) {
+		l.maxEntries = n
+	}
 }
 
 // New creates a new logger instance
-func New(service string) *Logger {
-	return &Logger{
+func New(service string, opts ...Option) *Logger {
+	l := &Logger{
 		service: service,
 		entries: make([]LogEntry, 0),
+		out:     os.Stdout,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// With returns a child logger that adds fields to the context of every
+// entry, such as a per-message correlation ID. Per-call context keys take
+// precedence over fields on collision. The child shares the parent's output,
+// deduplication, sampling and recorded entries.
+func (l *Logger) With(fields map[string]interface{}) *Logger {
+	return &Logger{
+		service: l.service,
+		root:    l.base(),
+		fields:  mergeContext(l.fields, fields),
+	}
+}
+
+// base returns the logger that owns the output and recorded entries
+func (l *Logger) base() *Logger {
+	if l.root != nil {
+		return l.root
 	}
+	return l
+}
+
+// mergeContext combines base fields with a per-call context, the latter
+// winning on collision. Neither map is modified.
+func mergeContext(fields, context map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return context
+	}
+	if len(context) == 0 {
+		return fields
+	}
+
+	merged := make(map[string]interface{}, len(fields)+len(context))
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key, value := range context {
+		merged[key] = value
+	}
+	return merged
 }
 
 // log creates and outputs a log entry
 func (l *Logger) log(level Level, message string, context map[string]interface{}) {
 	entry := LogEntry{
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Timestamp: l.base().timestamp(time.Now()),
 		Level:     level,
 		Message:   message,
 		Service:   l.service,
-		Context:   context,
+		Context:   mergeContext(l.fields, context),
 	}
+	l = l.base()
 
 	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabledLocked(level) {
+		return
+	}
+	if level == DEBUG && l.sampling != nil && !l.sampling.allow(message) {
+		return
+	}
+
+	if l.dedupWindow > 0 {
+		if l.pending != nil && l.pending.level == level && l.pending.message == message {
+			l.pending.repeats++
+			return
+		}
+		l.flushPendingLocked()
+		p := &pendingEntry{level: level, message: message}
+		p.timer = time.AfterFunc(l.dedupWindow, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.pending == p {
+				l.flushPendingLocked()
+			}
+		})
+		l.pending = p
+	}
+
+	l.emitLocked(entry)
+}
+
+// allow reports whether the next DEBUG entry with message should be logged;
+// callers must hold the logger mutex
+func (s *debugSampler) allow(message string) bool {
+	if s.window > 0 {
+		if now := time.Now(); now.Sub(s.windowStart) >= s.window {
+			s.windowStart = now
+			s.counts = make(map[string]int)
+		}
+	}
+
+	s.counts[message]++
+	n := s.counts[message]
+	if n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
+
+// emitLocked records and writes an entry; l.mu must be held
+func (l *Logger) emitLocked(entry LogEntry) {
 	l.entries = append(l.entries, entry)
-	l.mu.Unlock()
+	// Trim only once twice the limit is reached, so the copy is amortized
+	if l.maxEntries > 0 && len(l.entries) >= 2*l.maxEntries {
+		l.entries = append(l.entries[:0], l.entries[len(l.entries)-l.maxEntries:]...)
+	}
 
 	jsonBytes, err := json.Marshal(entry)
 	if err != nil {
@@ -62,7 +253,43 @@ func (l *Logger) log(level Level, message string, context map[string]interface{}
 		return
 	}
 
-	fmt.Println(string(jsonBytes))
+	w := l.out
+	if l.errOut != nil && (entry.Level == WARN || entry.Level == ERROR) {
+		w = l.errOut
+	}
+	fmt.Fprintln(w, string(jsonBytes))
+}
+
+// flushPendingLocked ends the current dedup run, emitting a summary line if
+// any repeats were suppressed; l.mu must be held
+func (l *Logger) flushPendingLocked() {
+	p := l.pending
+	if p == nil {
+		return
+	}
+	l.pending = nil
+	p.timer.Stop()
+
+	if p.repeats == 0 {
+		return
+	}
+	l.emitLocked(LogEntry{
+		Timestamp: l.timestamp(time.Now()),
+		Level:     p.level,
+		Message:   p.message,
+		Service:   l.service,
+		Context: map[string]interface{}{
+			"repeat_count": p.repeats,
+		},
+	})
+}
+
+// Flush emits any summary line still held back by deduplication
+func (l *Logger) Flush() {
+	l = l.base()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushPendingLocked()
 }
 
 
@@ -86,15 +313,32 @@ func (l *Logger) Error(message string, context map[string]interface{}) {
 	l.log(ERROR, message, context)
 }
 
-// GetEntries returns all logged entries (for testing)
+// GetEntries returns all logged entries (for testing), or the most recent
+// ones when WithMaxEntries is set
 func (l *Logger) GetEntries() []LogEntry {
+	return l.RecentEntries(0)
+}
+
+// RecentEntries returns up to the last n entries, oldest first. Zero or less
+// returns every entry kept.
+func (l *Logger) RecentEntries(n int) []LogEntry {
+	l = l.base()
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return append([]LogEntry{}, l.entries...)
+
+	entries := l.entries
+	if l.maxEntries > 0 && len(entries) > l.maxEntries {
+		entries = entries[len(entries)-l.maxEntries:]
+	}
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return append([]LogEntry{}, entries...)
 }
 
 // ClearEntries clears all logged entries (for testing)
 func (l *Logger) ClearEntries() {
+	l = l.base()
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.entries = make([]LogEntry, 0)
@@ -102,6 +346,7 @@ func (l *Logger) ClearEntries() {
 
 // HasLogWithMessage checks if a log entry with the given message exists (for testing)
 func (l *Logger) HasLogWithMessage(message string) bool {
+	l = l.base()
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	for _, entry := range l.entries {
@@ -114,6 +359,7 @@ func (l *Logger) HasLogWithMessage(message string) bool {
 
 // HasLogWithLevel checks if a log entry with the given level exists (for testing)
 func (l *Logger) HasLogWithLevel(level Level) bool {
+	l = l.base()
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	for _, entry := range l.entries {
@@ -0,0 +1,381 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Unit tests for structured logger options
+
+func TestLogger_NoDedupByDefault(t *testing.T) {
+	log := New("test")
+
+	for i := 0; i < 3; i++ {
+		log.Error("API returned error status", nil)
+	}
+
+	if got := len(log.GetEntries()); got != 3 {
+		t.Errorf("Expected 3 entries without dedup, got %d", got)
+	}
+}
+
+func TestLogger_DedupCollapsesRepeatsUntilMessageChanges(t *testing.T) {
+	log := New("test", WithDedup(time.Minute))
+
+	for i := 0; i < 5; i++ {
+		log.Error("API returned error status", nil)
+	}
+	log.Info("Message processed successfully", nil)
+
+	entries := log.GetEntries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries (first, summary, new message), got %d", len(entries))
+	}
+	if entries[0].Context != nil {
+		t.Errorf("Expected first occurrence to be emitted unchanged, got context %v", entries[0].Context)
+	}
+	if entries[1].Message != "API returned error status" || entries[1].Context["repeat_count"] != 4 {
+		t.Errorf("Expected summary with repeat_count 4, got %+v", entries[1])
+	}
+	if entries[2].Message != "Message processed successfully" {
+		t.Errorf("Expected new message after summary, got %q", entries[2].Message)
+	}
+}
+
+func TestLogger_DedupDistinguishesLevels(t *testing.T) {
+	log := New("test", WithDedup(time.Minute))
+
+	log.Warn("same text", nil)
+	log.Error("same text", nil)
+
+	if got := len(log.GetEntries()); got != 2 {
+		t.Errorf("Expected entries with different levels to both be emitted, got %d", got)
+	}
+}
+
+func TestLogger_DedupFlushesWhenWindowCloses(t *testing.T) {
+	log := New("test", WithDedup(20*time.Millisecond))
+
+	log.Error("API returned error status", nil)
+	log.Error("API returned error status", nil)
+
+	deadline := time.Now().Add(time.Second)
+	for len(log.GetEntries()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	entries := log.GetEntries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected summary entry after window closed, got %d entries", len(entries))
+	}
+	if entries[1].Context["repeat_count"] != 1 {
+		t.Errorf("Expected repeat_count 1, got %v", entries[1].Context["repeat_count"])
+	}
+}
+
+func TestLogger_FlushWithoutRepeatsEmitsNothing(t *testing.T) {
+	log := New("test", WithDedup(time.Minute))
+
+	log.Info("only once", nil)
+	log.Flush()
+
+	if got := len(log.GetEntries()); got != 1 {
+		t.Errorf("Expected no summary for a single entry, got %d entries", got)
+	}
+}
+
+func countLevel(entries []LogEntry, level Level) int {
+	n := 0
+	for _, entry := range entries {
+		if entry.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+func TestLogger_DebugSamplingOneInN(t *testing.T) {
+	log := New("test", WithDebugSampling(0, 10, 0))
+
+	for i := 0; i < 10000; i++ {
+		log.Debug("Sending to API", nil)
+	}
+
+	if got := len(log.GetEntries()); got != 1000 {
+		t.Errorf("Expected 1 in 10 debug entries (1000), got %d", got)
+	}
+}
+
+func TestLogger_DebugSamplingFirstThenEveryMth(t *testing.T) {
+	log := New("test", WithDebugSampling(5, 100, time.Hour))
+
+	for i := 0; i < 1005; i++ {
+		log.Debug("Sending to API", nil)
+	}
+
+	// first 5, then the 100th, 200th, ... 1000th after them
+	if got := len(log.GetEntries()); got != 15 {
+		t.Errorf("Expected 15 sampled entries, got %d", got)
+	}
+}
+
+func TestLogger_DebugSamplingPerMessage(t *testing.T) {
+	log := New("test", WithDebugSampling(1, 0, 0))
+
+	log.Debug("first message", nil)
+	log.Debug("first message", nil)
+	log.Debug("second message", nil)
+
+	if got := len(log.GetEntries()); got != 2 {
+		t.Errorf("Expected first occurrence of each message, got %d entries", got)
+	}
+}
+
+func TestLogger_DebugSamplingNeverDropsWarnOrError(t *testing.T) {
+	log := New("test", WithDebugSampling(0, 1000, 0))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				log.Debug("noisy", nil)
+				log.Info("info", nil)
+				log.Warn("warn", nil)
+				log.Error("error", nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries := log.GetEntries()
+	if got := countLevel(entries, DEBUG); got != 4 {
+		t.Errorf("Expected 4000/1000 = 4 debug entries, got %d", got)
+	}
+	for _, level := range []Level{INFO, WARN, ERROR} {
+		if got := countLevel(entries, level); got != 4000 {
+			t.Errorf("Expected all 4000 %s entries, got %d", level, got)
+		}
+	}
+}
+
+func TestLogger_SplitStreams(t *testing.T) {
+	var out, errOut bytes.Buffer
+	log := New("test", WithSplitStreams(&out, &errOut))
+
+	log.Debug("debug line", nil)
+	log.Info("info line", nil)
+	log.Warn("warn line", nil)
+	log.Error("error line", nil)
+
+	for _, msg := range []string{"debug line", "info line"} {
+		if !strings.Contains(out.String(), msg) || strings.Contains(errOut.String(), msg) {
+			t.Errorf("Expected %q only on the normal stream", msg)
+		}
+	}
+	for _, msg := range []string{"warn line", "error line"} {
+		if !strings.Contains(errOut.String(), msg) || strings.Contains(out.String(), msg) {
+			t.Errorf("Expected %q only on the error stream", msg)
+		}
+	}
+}
+
+// lineWriter fails the test if a write isn't exactly one complete line
+type lineWriter struct {
+	t     *testing.T
+	mu    sync.Mutex
+	lines int
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if bytes.Count(p, []byte("\n")) != 1 || p[len(p)-1] != '\n' {
+		w.t.Errorf("Expected one complete line per write, got %q", p)
+	}
+	w.lines++
+	return len(p), nil
+}
+
+func TestLogger_SplitStreamsConcurrentWrites(t *testing.T) {
+	out := &lineWriter{t: t}
+	errOut := &lineWriter{t: t}
+	log := New("test", WithSplitStreams(out, errOut))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				log.Info("info", nil)
+				log.Error("error", nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if out.lines != 800 || errOut.lines != 800 {
+		t.Errorf("Expected 800 lines per stream, got %d and %d", out.lines, errOut.lines)
+	}
+}
+
+func TestLogger_WithMergesContext(t *testing.T) {
+	log := New("test")
+	child := log.With(map[string]interface{}{"request_id": "x"})
+
+	child.Info("Processing message", map[string]interface{}{"delivery_tag": "y"})
+
+	entries := log.GetEntries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected child entry recorded on the parent, got %d entries", len(entries))
+	}
+	if entries[0].Context["request_id"] != "x" || entries[0].Context["delivery_tag"] != "y" {
+		t.Errorf("Expected both request_id and delivery_tag, got %v", entries[0].Context)
+	}
+}
+
+func TestLogger_WithPerCallContextWins(t *testing.T) {
+	log := New("test")
+	fields := map[string]interface{}{"request_id": "x", "source": "base"}
+	child := log.With(fields).With(map[string]interface{}{"attempt": 1})
+
+	context := map[string]interface{}{"source": "call"}
+	child.Warn("API request failed", context)
+
+	entry := log.GetEntries()[0]
+	if entry.Context["source"] != "call" {
+		t.Errorf("Expected per-call source to win, got %v", entry.Context["source"])
+	}
+	if entry.Context["request_id"] != "x" || entry.Context["attempt"] != 1 {
+		t.Errorf("Expected fields inherited from both With calls, got %v", entry.Context)
+	}
+	if fields["source"] != "base" || len(context) != 1 {
+		t.Error("Expected merging to leave the caller's maps unchanged")
+	}
+}
+
+func TestLogger_MaxEntriesKeepsMostRecent(t *testing.T) {
+	log := New("test", WithMaxEntries(3), WithSplitStreams(&bytes.Buffer{}, &bytes.Buffer{}))
+
+	for i := 0; i < 10; i++ {
+		log.Info("Message processed successfully", map[string]interface{}{"n": i})
+	}
+
+	entries := log.GetEntries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries kept, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Context["n"] != 7+i {
+			t.Errorf("Expected entries 7-9 oldest first, got %v at %d", entry.Context["n"], i)
+		}
+	}
+	if got := len(log.base().entries); got >= 6 {
+		t.Errorf("Expected the buffer to stay under twice the limit, got %d", got)
+	}
+}
+
+func TestLogger_RecentEntries(t *testing.T) {
+	log := New("test", WithSplitStreams(&bytes.Buffer{}, &bytes.Buffer{}))
+	log.Info("first", nil)
+	log.Info("second", nil)
+	log.Info("third", nil)
+
+	recent := log.RecentEntries(2)
+	if len(recent) != 2 || recent[0].Message != "second" || recent[1].Message != "third" {
+		t.Errorf("Expected the last two entries, got %+v", recent)
+	}
+	if got := len(log.RecentEntries(0)); got != 3 {
+		t.Errorf("Expected every entry for n=0, got %d", got)
+	}
+}
+
+func TestLogger_WithLevelDropsLowerLevels(t *testing.T) {
+	log := New("test", WithLevel(WARN), WithSplitStreams(&bytes.Buffer{}, &bytes.Buffer{}))
+	log.Debug("debug", nil)
+	log.Info("info", nil)
+	log.Warn("warn", nil)
+	log.Error("error", nil)
+
+	entries := log.GetEntries()
+	if len(entries) != 2 || entries[0].Level != WARN || entries[1].Level != ERROR {
+		t.Errorf("Expected only WARN and ERROR entries, got %+v", entries)
+	}
+}
+
+func TestLogger_SetLevelAppliesToChildren(t *testing.T) {
+	log := New("test", WithSplitStreams(&bytes.Buffer{}, &bytes.Buffer{}))
+	child := log.With(map[string]interface{}{"delivery_tag": 1})
+
+	log.SetLevel(ERROR)
+	child.Warn("warn", nil)
+	if log.HasLogWithMessage("warn") {
+		t.Error("Expected the child's WARN entry to be dropped")
+	}
+	if log.MinLevel() != ERROR {
+		t.Errorf("Expected ERROR, got %s", log.MinLevel())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, ok := ParseLevel(" info "); !ok || level != INFO {
+		t.Errorf("Expected INFO, got %q (ok=%v)", level, ok)
+	}
+	if _, ok := ParseLevel("verbose"); ok {
+		t.Error("Expected unknown level to be rejected")
+	}
+}
+
+func TestLogger_DefaultTimestampIsUTCSeconds(t *testing.T) {
+	log := New("test", WithSplitStreams(&bytes.Buffer{}, &bytes.Buffer{}))
+
+	log.Info("hello", nil)
+
+	ts := log.GetEntries()[0].Timestamp
+	parsed, err := time.Parse(time.RFC3339, ts)
+	if err != nil || !strings.HasSuffix(ts, "Z") || strings.Contains(ts, ".") {
+		t.Errorf("Expected a second-precision UTC timestamp, got %q (%v)", ts, err)
+	}
+	if parsed.Location() != time.UTC {
+		t.Errorf("Expected UTC, got %v", parsed.Location())
+	}
+}
+
+func TestLogger_TimestampFormatAndTimezone(t *testing.T) {
+	zone := time.FixedZone("BRT", -3*60*60)
+	log := New("test", WithTimestampFormat(time.RFC3339Nano), WithTimezone(zone))
+	at := time.Date(2025, 12, 3, 14, 30, 0, 123456789, time.UTC)
+
+	if got := log.timestamp(at); got != "2025-12-03T11:30:00.123456789-03:00" {
+		t.Errorf("Expected nanoseconds in -03:00, got %q", got)
+	}
+}
+
+func TestLogger_RecordedAndEmittedTimestampsMatch(t *testing.T) {
+	var out bytes.Buffer
+	log := New("test", WithSplitStreams(&out, &out), WithTimestampFormat(time.RFC3339Nano), WithTimezone(time.FixedZone("BRT", -3*60*60)))
+
+	log.With(map[string]interface{}{"k": "v"}).Info("hello", nil)
+
+	ts := log.GetEntries()[0].Timestamp
+	if !strings.HasSuffix(ts, "-03:00") {
+		t.Errorf("Expected the child entry in the configured zone, got %q", ts)
+	}
+	if !strings.Contains(out.String(), `"timestamp":"`+ts+`"`) {
+		t.Errorf("Expected emitted JSON to carry %q, got %s", ts, out.String())
+	}
+}
+
+func TestParseTimestampFormat(t *testing.T) {
+	if layout, ok := ParseTimestampFormat(" RFC3339Nano "); !ok || layout != time.RFC3339Nano {
+		t.Errorf("Expected rfc3339nano to parse, got %q %v", layout, ok)
+	}
+	if _, ok := ParseTimestampFormat("unix"); ok {
+		t.Error("Expected unknown format to be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"strings"
+	"time"
+)
+
+// Timestamp formats accepted by ParseTimestampFormat
+const (
+	FormatRFC3339     = "rfc3339"     // second precision, the default
+	FormatRFC3339Nano = "rfc3339nano" // up to nanosecond precision
+)
+
+var timestampLayouts = map[string]string{
+	FormatRFC3339:     time.RFC3339,
+	FormatRFC3339Nano: time.RFC3339Nano,
+}
+
+// ParseTimestampFormat returns the time layout for a timestamp format name,
+// case-insensitively
+func ParseTimestampFormat(name string) (string, bool) {
+	layout, ok := timestampLayouts[strings.ToLower(strings.TrimSpace(name))]
+	return layout, ok
+}
+
+// WithTimestampFormat formats entry timestamps with layout, such as
+// time.RFC3339Nano for sub-second precision. The default is time.RFC3339.
+func WithTimestampFormat(layout string) Option {
+	return func(l *Logger) {
+		l.timeLayout = layout
+	}
+}
+
+// WithTimezone renders entry timestamps in loc, such as time.Local, instead
+// of UTC. A nil loc keeps UTC.
+func WithTimezone(loc *time.Location) Option {
+	return func(l *Logger) {
+		l.timeZone = loc
+	}
+}
+
+// timestamp formats t for an entry. Recorded entries and the emitted JSON
+// share the one string.
+func (l *Logger) timestamp(t time.Time) string {
+	layout := l.timeLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	if l.timeZone == nil {
+		return t.UTC().Format(layout)
+	}
+	return t.In(l.timeZone).Format(layout)
+}
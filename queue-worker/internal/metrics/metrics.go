@@ -0,0 +1,364 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds metrics and renders them in the Prometheus text format
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// collector is a registered metric that can render itself
+type collector interface {
+	write(w io.Writer)
+}
+
+// NewRegistry creates an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter is a monotonically increasing metric, optionally partitioned by labels
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*sample
+}
+
+// Sample is the value of a metric for one combination of label values
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*sample),
+	}
+
+	r.register(c)
+	return c
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mu.Unlock()
+}
+
+// Inc increments the counter for the given label values by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by delta
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	key := seriesKey(c.name, c.labels, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		c.series[key] = s
+	}
+	s.value += delta
+}
+
+// Value returns the current value for the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.series[strings.Join(labelValues, keySeparator)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// Samples returns a snapshot of every series, ordered by label values
+func (c *Counter) Samples() []Sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := sortedKeys(c.series)
+	samples := make([]Sample, 0, len(keys))
+	for _, key := range keys {
+		s := c.series[key]
+		samples = append(samples, Sample{
+			LabelValues: append([]string(nil), s.labelValues...),
+			Value:       s.value,
+		})
+	}
+	return samples
+}
+
+// Total returns the sum of the counter across all label values
+func (c *Counter) Total() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := 0.0
+	for _, s := range c.series {
+		total += s.value
+	}
+	return total
+}
+
+func (c *Counter) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+	for _, s := range c.Samples() {
+		fmt.Fprintf(w, "%s%s %g\n", c.name, formatLabels(c.labels, s.LabelValues), s.Value)
+	}
+}
+
+// Gauge is a metric that can go up and down, optionally partitioned by labels
+type Gauge struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*sample
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*sample),
+	}
+
+	r.register(g)
+	return g
+}
+
+// Set sets the gauge for the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	key := seriesKey(g.name, g.labels, labelValues)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.series[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		g.series[key] = s
+	}
+	s.value = value
+}
+
+// Value returns the current value for the given label values
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s, ok := g.series[strings.Join(labelValues, keySeparator)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (g *Gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range sortedKeys(g.series) {
+		s := g.series[key]
+		fmt.Fprintf(w, "%s%s %g\n", g.name, formatLabels(g.labels, s.labelValues), s.value)
+	}
+}
+
+// Histogram counts observations into cumulative buckets, optionally
+// partitioned by labels
+type Histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSample
+}
+
+type histogramSample struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// DefaultBuckets suit request latencies in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewHistogram registers a histogram with the given upper bucket bounds
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: append([]float64(nil), buckets...),
+		series:  make(map[string]*histogramSample),
+	}
+	sort.Float64s(h.buckets)
+
+	r.register(h)
+	return h
+}
+
+// Observe records a value for the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := seriesKey(h.name, h.labels, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSample{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// Count returns the number of observations for the given label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[strings.Join(labelValues, keySeparator)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", h.name)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		bucketLabels := append(append([]string(nil), h.labels...), "le")
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			values := append(append([]string(nil), s.labelValues...), fmt.Sprintf("%g", bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, values), cumulative)
+		}
+		values := append(append([]string(nil), s.labelValues...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, values), s.count)
+		fmt.Fprintf(w, "%s_sum%s %g\n", h.name, formatLabels(h.labels, s.labelValues), s.sum)
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.labelValues), s.count)
+	}
+}
+
+// WriteTo writes all registered metrics in the Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, c := range collectors {
+		c.write(cw)
+	}
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP exposes the registry as a Prometheus scrape endpoint
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WriteTo(w)
+}
+
+const keySeparator = "\xff"
+
+// seriesKey identifies a series by its label values, panicking when the
+// number of values doesn't match the metric's label names
+func seriesKey(name string, labels, labelValues []string) string {
+	if len(labelValues) != len(labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, keySeparator)
+}
+
+// sortedKeys returns map keys in order so output is stable across scrapes
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatLabels renders {name="value",...} for a series, or nothing when unlabeled
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// countingWriter tracks bytes written and the first write error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
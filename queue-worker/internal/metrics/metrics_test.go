@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Unit tests for the metrics registry and Prometheus exposition
+
+func TestCounter_IncAndValue(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("test_total", "A test counter.", "field")
+
+	c.Inc("a")
+	c.Inc("a")
+	c.Add(3, "b")
+
+	if got := c.Value("a"); got != 2 {
+		t.Errorf("Expected 2 for label a, got %v", got)
+	}
+	if got := c.Value("b"); got != 3 {
+		t.Errorf("Expected 3 for label b, got %v", got)
+	}
+	if got := c.Value("missing"); got != 0 {
+		t.Errorf("Expected 0 for unseen label, got %v", got)
+	}
+}
+
+func TestCounter_WrongLabelCountPanics(t *testing.T) {
+	c := NewRegistry().NewCounter("test_total", "A test counter.", "field", "code")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for wrong number of label values")
+		}
+	}()
+	c.Inc("only-one")
+}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("plain_total", "Unlabeled counter.").Inc()
+	labeled := reg.NewCounter("labeled_total", "Labeled counter.", "field", "code")
+	labeled.Inc("weather.humidity", "out_of_range")
+	labeled.Inc(`we"ird`, "required")
+
+	var buf bytes.Buffer
+	if _, err := reg.WriteTo(&buf); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"# HELP plain_total Unlabeled counter.\n",
+		"# TYPE plain_total counter\n",
+		"plain_total 1\n",
+		"# TYPE labeled_total counter\n",
+		`labeled_total{field="weather.humidity",code="out_of_range"} 1` + "\n",
+		`labeled_total{field="we\"ird",code="required"} 1` + "\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, out)
+		}
+	}
+}
+
+func TestHistogram_WriteTo(t *testing.T) {
+	reg := NewRegistry()
+	h := reg.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "source")
+	h.Observe(0.05, "open-meteo")
+	h.Observe(0.5, "open-meteo")
+	h.Observe(5, "open-meteo")
+
+	if got := h.Count("open-meteo"); got != 3 {
+		t.Errorf("Expected 3 observations, got %d", got)
+	}
+
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE latency_seconds histogram\n",
+		`latency_seconds_bucket{source="open-meteo",le="0.1"} 1` + "\n",
+		`latency_seconds_bucket{source="open-meteo",le="1"} 2` + "\n",
+		`latency_seconds_bucket{source="open-meteo",le="+Inf"} 3` + "\n",
+		`latency_seconds_sum{source="open-meteo"} 5.55` + "\n",
+		`latency_seconds_count{source="open-meteo"} 3` + "\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, out)
+		}
+	}
+}
+
+func TestCounter_Total(t *testing.T) {
+	c := NewRegistry().NewCounter("test_total", "A test counter.", "source")
+	c.Inc("a")
+	c.Add(2, "b")
+
+	if got := c.Total(); got != 3 {
+		t.Errorf("Expected total 3, got %v", got)
+	}
+}
+
+func TestGauge_SetAndWriteTo(t *testing.T) {
+	reg := NewRegistry()
+	g := reg.NewGauge("up", "Whether the thing is up.")
+	g.Set(1)
+	g.Set(0)
+
+	if got := g.Value(); got != 0 {
+		t.Errorf("Expected gauge to hold last value 0, got %v", got)
+	}
+
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+	out := buf.String()
+
+	for _, line := range []string{"# TYPE up gauge\n", "up 0\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, out)
+		}
+	}
+}
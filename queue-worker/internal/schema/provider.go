@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider returns the schema registered for a message source and schema
+// version
+type Provider interface {
+	Schema(source string, version int) (*Schema, error)
+}
+
+// URL template placeholders filled for each schema lookup
+const (
+	PlaceholderSource  = "{source}"
+	PlaceholderVersion = "{version}"
+)
+
+// HTTPProvider fetches schemas from a registry with a GET to a URL template
+// such as "http://registry/schemas/{source}/{version}"
+type HTTPProvider struct {
+	urlTemplate string
+	client      *http.Client
+}
+
+// NewHTTPProvider creates a provider for the registry at urlTemplate. A nil
+// client uses one with a 10 second timeout.
+func NewHTTPProvider(urlTemplate string, client *http.Client) *HTTPProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPProvider{urlTemplate: urlTemplate, client: client}
+}
+
+// Schema implements Provider
+func (p *HTTPProvider) Schema(source string, version int) (*Schema, error) {
+	schemaURL := strings.NewReplacer(
+		PlaceholderSource, url.PathEscape(source),
+		PlaceholderVersion, strconv.Itoa(version),
+	).Replace(p.urlTemplate)
+
+	resp, err := p.client.Get(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schema registry returned status %d for %s version %d", resp.StatusCode, source, version)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	return Parse(data)
+}
+
+// CachedProvider keeps schemas from another provider for a TTL. When a
+// refresh fails, the expired schema is served until one succeeds, so a
+// registry outage doesn't affect sources already seen.
+type CachedProvider struct {
+	next Provider
+	ttl  time.Duration
+	now  func() time.Time
+
+	// OnError, if set, is called with each failed lookup, including those
+	// answered from an expired entry
+	OnError func(source string, version int, err error)
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+type cacheKey struct {
+	source  string
+	version int
+}
+
+type cacheEntry struct {
+	schema    *Schema
+	fetchedAt time.Time
+}
+
+// NewCachedProvider caches the schemas next returns for ttl
+func NewCachedProvider(next Provider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		next:    next,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[cacheKey]cacheEntry),
+	}
+}
+
+// Schema implements Provider
+func (p *CachedProvider) Schema(source string, version int) (*Schema, error) {
+	key := cacheKey{source: source, version: version}
+
+	p.mu.Lock()
+	entry, cached := p.entries[key]
+	p.mu.Unlock()
+	if cached && p.now().Sub(entry.fetchedAt) < p.ttl {
+		return entry.schema, nil
+	}
+
+	s, err := p.next.Schema(source, version)
+	if err != nil {
+		if p.OnError != nil {
+			p.OnError(source, version, err)
+		}
+		if cached {
+			return entry.schema, nil
+		}
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.entries[key] = cacheEntry{schema: s, fetchedAt: p.now()}
+	p.mu.Unlock()
+	return s, nil
+}
@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Unit tests for schema providers
+
+func TestHTTPProvider_FetchesBySourceAndVersion(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		if r.URL.Path == "/schemas/missing/1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"type": "object", "required": ["source"]}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(server.URL+"/schemas/{source}/{version}", nil)
+
+	s, err := provider.Schema("open meteo", 2)
+	if err != nil {
+		t.Fatalf("Expected schema, got: %v", err)
+	}
+	if path != "/schemas/open%20meteo/2" {
+		t.Errorf("Expected escaped source and version in path, got %s", path)
+	}
+	if len(s.Required) != 1 || s.Required[0] != "source" {
+		t.Errorf("Expected parsed schema, got %+v", s)
+	}
+
+	if _, err := provider.Schema("missing", 1); err == nil {
+		t.Error("Expected an error for a 404")
+	}
+}
+
+// countingProvider returns a fixed schema or error, counting lookups
+type countingProvider struct {
+	schema *Schema
+	err    error
+	calls  int
+}
+
+func (p *countingProvider) Schema(source string, version int) (*Schema, error) {
+	p.calls++
+	return p.schema, p.err
+}
+
+func TestCachedProvider_CachesUntilTTL(t *testing.T) {
+	next := &countingProvider{schema: &Schema{}}
+	provider := NewCachedProvider(next, time.Minute)
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	provider.Schema("open-meteo", 1)
+	provider.Schema("open-meteo", 1)
+	if next.calls != 1 {
+		t.Errorf("Expected one fetch within the TTL, got %d", next.calls)
+	}
+
+	provider.Schema("open-meteo", 2)
+	if next.calls != 2 {
+		t.Errorf("Expected versions to be cached separately, got %d fetches", next.calls)
+	}
+
+	now = now.Add(time.Minute)
+	provider.Schema("open-meteo", 1)
+	if next.calls != 3 {
+		t.Errorf("Expected a refetch after the TTL, got %d fetches", next.calls)
+	}
+}
+
+func TestCachedProvider_ServesExpiredSchemaOnError(t *testing.T) {
+	cached := &Schema{}
+	next := &countingProvider{schema: cached}
+	provider := NewCachedProvider(next, time.Minute)
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	var failures int
+	provider.OnError = func(source string, version int, err error) { failures++ }
+
+	provider.Schema("open-meteo", 1)
+	next.schema, next.err = nil, errors.New("registry down")
+	now = now.Add(2 * time.Minute)
+
+	s, err := provider.Schema("open-meteo", 1)
+	if err != nil || s != cached {
+		t.Errorf("Expected the expired schema while the registry is down, got %v, %v", s, err)
+	}
+	if _, err := provider.Schema("openweather", 1); err == nil {
+		t.Error("Expected an error for a source never fetched")
+	}
+	if failures != 2 {
+		t.Errorf("Expected OnError for each failed fetch, got %d", failures)
+	}
+}
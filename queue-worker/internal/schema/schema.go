@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema document. Only the keywords needed to describe a
+// message's shape are supported: type, properties, required,
+// additionalProperties (as a boolean), items, enum, minimum, maximum,
+// minLength and maxLength, plus annotations such as title and description.
+// Parse rejects any other keyword, so a schema never silently accepts what
+// an unsupported keyword ($ref, pattern, oneOf, format, ...) would reject.
+type Schema struct {
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"-"`
+	Items                *Schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+}
+
+// Violation is one way a document doesn't match a schema. Path is the dotted
+// path of the offending value, empty for the document itself.
+type Violation struct {
+	Path    string
+	Message string
+}
+
+// Parse decodes a JSON Schema document
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// supportedKeywords are the keywords Validate enforces, and annotations,
+// which don't affect validation
+var supportedKeywords = map[string]bool{
+	"type": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "enum": true, "minimum": true, "maximum": true,
+	"minLength": true, "maxLength": true,
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true,
+}
+
+// UnmarshalJSON decodes a schema, rejecting unsupported keywords and a
+// non-boolean additionalProperties
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	var unsupported []string
+	for keyword := range keywords {
+		if !supportedKeywords[keyword] {
+			unsupported = append(unsupported, keyword)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("unsupported keywords: %s", strings.Join(unsupported, ", "))
+	}
+
+	type plain Schema
+	var decoded struct {
+		plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*s = Schema(decoded.plain)
+	if decoded.AdditionalProperties != nil {
+		var allowed bool
+		if err := json.Unmarshal(decoded.AdditionalProperties, &allowed); err != nil {
+			return fmt.Errorf("additionalProperties must be a boolean")
+		}
+		s.AdditionalProperties = &allowed
+	}
+	return nil
+}
+
+// typeList holds the "type" keyword, which may be a name or a list of names
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = typeList{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = names
+	return nil
+}
+
+// Validate checks a document decoded with encoding/json into interface{}
+// values, returning every violation in a stable order
+func (s *Schema) Validate(doc interface{}) []Violation {
+	var violations []Violation
+	s.validate(doc, "", &violations)
+	return violations
+}
+
+func (s *Schema) validate(value interface{}, path string, violations *[]Violation) {
+	add := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		add("must be of type %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		add("must be one of the allowed values")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(v, path, violations)
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, join(path, fmt.Sprint(i)), violations)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			add("must be at least %g", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			add("must be at most %g", *s.Maximum)
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			add("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			add("must be at most %d characters", *s.MaxLength)
+		}
+	}
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, path string, violations *[]Violation) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			*violations = append(*violations, Violation{Path: join(path, name), Message: "required property is missing"})
+		}
+	}
+
+	// Sorted so violations come out in the same order every time
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		switch {
+		case ok:
+			property.validate(obj[name], join(path, name), violations)
+		case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+			*violations = append(*violations, Violation{Path: join(path, name), Message: "property is not allowed"})
+		}
+	}
+}
+
+// matchesType reports whether value is of one of the schema's types
+func (s *Schema) matchesType(value interface{}) bool {
+	for _, name := range s.Type {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case float64:
+			if name == "number" || (name == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case nil:
+			if name == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Schema) inEnum(value interface{}) bool {
+	for _, allowed := range s.Enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// join appends a property name or array index to a dotted path
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
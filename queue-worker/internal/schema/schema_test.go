@@ -0,0 +1,147 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Unit tests for the JSON Schema subset
+
+const weatherSchema = `{
+	"type": "object",
+	"required": ["timestamp", "location", "weather", "source"],
+	"properties": {
+		"timestamp": {"type": "string", "minLength": 1},
+		"location": {
+			"type": "object",
+			"required": ["city"],
+			"properties": {
+				"city": {"type": "string", "minLength": 1, "maxLength": 10},
+				"latitude": {"type": "number", "minimum": -90, "maximum": 90}
+			}
+		},
+		"weather": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"temperature": {"type": "number"},
+				"condition": {"enum": ["sunny", "cloudy", "rainy"]},
+				"humidity": {"type": "integer"}
+			}
+		},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"source": {"type": ["string", "null"]}
+	}
+}`
+
+func decode(t *testing.T, body string) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("Invalid test JSON: %v", err)
+	}
+	return doc
+}
+
+func TestValidate_AcceptsMatchingDocument(t *testing.T) {
+	s, err := Parse([]byte(weatherSchema))
+	if err != nil {
+		t.Fatalf("Expected schema to parse, got: %v", err)
+	}
+
+	doc := decode(t, `{"timestamp":"2025-12-03T14:30:00Z","location":{"city":"Recife","latitude":-8.05},"weather":{"temperature":28.5,"condition":"sunny","humidity":65},"tags":["a"],"source":null,"extra":true}`)
+	if violations := s.Validate(doc); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %+v", violations)
+	}
+}
+
+func TestValidate_ReportsViolationsWithPaths(t *testing.T) {
+	s, _ := Parse([]byte(weatherSchema))
+
+	doc := decode(t, `{"timestamp":"","location":{"city":"São Paulo do Norte","latitude":91},"weather":{"temperature":"hot","condition":"foggy","humidity":65.5,"wind":3},"tags":["a",1]}`)
+	got := map[string]string{}
+	for _, v := range s.Validate(doc) {
+		got[v.Path] = v.Message
+	}
+
+	expected := map[string]string{
+		"source":              "required property is missing",
+		"timestamp":           "must be at least 1 characters",
+		"location.city":       "must be at most 10 characters",
+		"location.latitude":   "must be at most 90",
+		"weather.temperature": "must be of type number",
+		"weather.condition":   "must be one of the allowed values",
+		"weather.humidity":    "must be of type integer",
+		"weather.wind":        "property is not allowed",
+		"tags.1":              "must be of type string",
+	}
+	for path, message := range expected {
+		if got[path] != message {
+			t.Errorf("Expected %s: %q, got %q", path, message, got[path])
+		}
+	}
+	if len(got) != len(expected) {
+		t.Errorf("Expected %d violations, got %+v", len(expected), got)
+	}
+}
+
+func TestValidate_RootTypeMismatch(t *testing.T) {
+	s, _ := Parse([]byte(weatherSchema))
+
+	violations := s.Validate(decode(t, `[1, 2]`))
+	if len(violations) != 1 || violations[0].Path != "" {
+		t.Errorf("Expected a single root violation, got %+v", violations)
+	}
+}
+
+func TestValidate_StableOrder(t *testing.T) {
+	s, _ := Parse([]byte(weatherSchema))
+	doc := decode(t, `{"timestamp":1,"location":1,"weather":1,"source":1}`)
+
+	first := s.Validate(doc)
+	for i := 0; i < 10; i++ {
+		again := s.Validate(doc)
+		for j := range first {
+			if again[j] != first[j] {
+				t.Fatalf("Expected the same order on every run, got %+v and %+v", first, again)
+			}
+		}
+	}
+}
+
+func TestParse_RejectsInvalidSchema(t *testing.T) {
+	if _, err := Parse([]byte(`{"type": 5}`)); err == nil {
+		t.Error("Expected a non-string type to fail")
+	}
+	if _, err := Parse([]byte(`not json`)); err == nil {
+		t.Error("Expected invalid JSON to fail")
+	}
+}
+
+func TestParse_RejectsUnsupportedKeywords(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"top-level ref", `{"$ref": "#/definitions/weather"}`},
+		{"nested pattern", `{"properties": {"city": {"type": "string", "pattern": "^[A-Z]"}}}`},
+		{"oneOf in items", `{"items": {"oneOf": [{"type": "string"}, {"type": "number"}]}}`},
+		{"format", `{"type": "string", "format": "date-time"}`},
+		{"schema-valued additionalProperties", `{"additionalProperties": {"type": "string"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.schema)); err == nil {
+				t.Errorf("Expected %s to be rejected", tt.schema)
+			}
+		})
+	}
+}
+
+func TestParse_AcceptsAnnotations(t *testing.T) {
+	schema := `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "Weather", "description": "A reading", "type": "object"}`
+	if _, err := Parse([]byte(schema)); err != nil {
+		t.Errorf("Expected annotations to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"queue-worker/internal/validator"
+)
+
+// ErrFull is returned when appending would grow the spool past its size limit
+var ErrFull = errors.New("spool is full")
+
+// Spool is a bounded, file-backed buffer of validated messages kept while the
+// API is unavailable. Messages are stored one JSON object per line.
+type Spool struct {
+	path     string
+	maxBytes int64
+
+	mu   sync.Mutex
+	size int64
+
+	drainMu sync.Mutex // one Drain at a time; Drain only holds mu to read and rewrite
+}
+
+// Open opens (or creates) the spool file at path, limited to maxBytes
+func Open(path string, maxBytes int64) (*Spool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spool{path: path, maxBytes: maxBytes, size: info.Size()}, nil
+}
+
+// Append durably writes msg to the end of the spool
+func (s *Spool) Append(msg *validator.WeatherMessage) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size+int64(len(line)) > s.maxBytes {
+		return ErrFull
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(line); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	s.size += int64(len(line))
+	return nil
+}
+
+// Size returns the current spool size in bytes
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// Drain re-submits spooled messages in order through send, stopping at the
+// first one send rejects. Messages not yet sent stay in the spool, along with
+// any appended while draining: send runs without the lock, so Append and Size
+// don't wait on the API. It returns the number of messages sent.
+func (s *Spool) Drain(send func(*validator.WeatherMessage) bool) (int, error) {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	s.mu.Lock()
+	data, err := os.ReadFile(s.path)
+	s.mu.Unlock()
+	if err != nil || len(data) == 0 {
+		return 0, err
+	}
+
+	sent := 0
+	rest := data
+	for len(rest) > 0 {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i]
+		}
+
+		var msg validator.WeatherMessage
+		if err := json.Unmarshal(line, &msg); err == nil {
+			if !send(&msg) {
+				break
+			}
+			sent++
+		}
+		// skip past the line whether it was sent or unreadable
+		rest = rest[min(len(line)+1, len(rest)):]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Only Append changed the file meanwhile, adding lines after data
+	current, err := os.ReadFile(s.path)
+	if err != nil {
+		return sent, err
+	}
+	if err := s.rewrite(current[len(data)-len(rest):]); err != nil {
+		return sent, err
+	}
+	return sent, nil
+}
+
+// rewrite atomically replaces the spool contents with remaining
+func (s *Spool) rewrite(remaining []byte) error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if _, err := w.Write(remaining); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.size = int64(len(remaining))
+	return nil
+}
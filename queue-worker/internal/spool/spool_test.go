@@ -0,0 +1,137 @@
+package spool
+
+import (
+	"path/filepath"
+	"testing"
+
+	"queue-worker/internal/validator"
+)
+
+// Unit tests for the on-disk message spool
+
+func createTestMessage(city string) *validator.WeatherMessage {
+	rainProbability := 30.0
+	return &validator.WeatherMessage{
+		Timestamp: "2025-12-03T14:30:00Z",
+		Location:  validator.Location{City: city, Latitude: -23.5505, Longitude: -46.6333},
+		Weather:   validator.Weather{Temperature: 28.5, Humidity: 65, WindSpeed: 12.3, Condition: "partly_cloudy", RainProbability: &rainProbability},
+		Source:    "open-meteo",
+	}
+}
+
+func openTestSpool(t *testing.T, maxBytes int64) *Spool {
+	s, err := Open(filepath.Join(t.TempDir(), "spool.ndjson"), maxBytes)
+	if err != nil {
+		t.Fatalf("Expected spool to open, got: %v", err)
+	}
+	return s
+}
+
+func TestSpool_AppendAndDrainInOrder(t *testing.T) {
+	s := openTestSpool(t, 1<<20)
+	for _, city := range []string{"A", "B", "C"} {
+		if err := s.Append(createTestMessage(city)); err != nil {
+			t.Fatalf("Expected append to succeed, got: %v", err)
+		}
+	}
+
+	var cities []string
+	sent, err := s.Drain(func(msg *validator.WeatherMessage) bool {
+		cities = append(cities, msg.Location.City)
+		return true
+	})
+
+	if err != nil || sent != 3 {
+		t.Fatalf("Expected 3 sent without error, got %d, %v", sent, err)
+	}
+	if len(cities) != 3 || cities[0] != "A" || cities[2] != "C" {
+		t.Errorf("Expected messages in append order, got %v", cities)
+	}
+	if s.Size() != 0 {
+		t.Errorf("Expected empty spool after full drain, got %d bytes", s.Size())
+	}
+}
+
+func TestSpool_DrainKeepsUnsentMessages(t *testing.T) {
+	s := openTestSpool(t, 1<<20)
+	for _, city := range []string{"A", "B", "C"} {
+		s.Append(createTestMessage(city))
+	}
+
+	sent, _ := s.Drain(func(msg *validator.WeatherMessage) bool {
+		return msg.Location.City == "A"
+	})
+	if sent != 1 {
+		t.Errorf("Expected 1 sent before failure, got %d", sent)
+	}
+
+	var remaining []string
+	s.Drain(func(msg *validator.WeatherMessage) bool {
+		remaining = append(remaining, msg.Location.City)
+		return true
+	})
+	if len(remaining) != 2 || remaining[0] != "B" || remaining[1] != "C" {
+		t.Errorf("Expected B and C to remain spooled, got %v", remaining)
+	}
+}
+
+func TestSpool_AppendDuringDrainIsKept(t *testing.T) {
+	s := openTestSpool(t, 1<<20)
+	s.Append(createTestMessage("A"))
+
+	sent, err := s.Drain(func(msg *validator.WeatherMessage) bool {
+		// Would deadlock if Drain held the lock while sending
+		if err := s.Append(createTestMessage("B")); err != nil {
+			t.Errorf("Expected append during drain to succeed, got: %v", err)
+		}
+		return s.Size() > 0
+	})
+	if err != nil || sent != 1 {
+		t.Fatalf("Expected 1 sent without error, got %d, %v", sent, err)
+	}
+
+	var remaining []string
+	s.Drain(func(msg *validator.WeatherMessage) bool {
+		remaining = append(remaining, msg.Location.City)
+		return true
+	})
+	if len(remaining) != 1 || remaining[0] != "B" {
+		t.Errorf("Expected the message appended during the drain to remain, got %v", remaining)
+	}
+}
+
+func TestSpool_RejectsWhenFull(t *testing.T) {
+	s := openTestSpool(t, 300)
+
+	var err error
+	appended := 0
+	for i := 0; i < 10 && err == nil; i++ {
+		if err = s.Append(createTestMessage("São Paulo")); err == nil {
+			appended++
+		}
+	}
+
+	if err != ErrFull {
+		t.Fatalf("Expected ErrFull, got: %v", err)
+	}
+	if s.Size() > 300 {
+		t.Errorf("Expected spool to stay within 300 bytes, got %d", s.Size())
+	}
+	if appended == 0 {
+		t.Error("Expected at least one message to fit")
+	}
+}
+
+func TestSpool_ReopenKeepsContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.ndjson")
+	s, _ := Open(path, 1<<20)
+	s.Append(createTestMessage("A"))
+
+	reopened, err := Open(path, 1<<20)
+	if err != nil {
+		t.Fatalf("Expected reopen to succeed, got: %v", err)
+	}
+	if reopened.Size() != s.Size() {
+		t.Errorf("Expected reopened size %d, got %d", s.Size(), reopened.Size())
+	}
+}
@@ -0,0 +1,148 @@
+//go:build testgen
+
+// Package testgen generates random valid and targeted-invalid weather
+// messages for property tests. It is built only with the testgen tag, so
+// gopter stays out of the worker's build; run its users with
+// go test -tags testgen ./...
+package testgen
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"queue-worker/internal/validator"
+)
+
+// Invalid is a generated message with exactly one field out of range
+type Invalid struct {
+	Field   string // path of the bad field, as in validator.ValidationError
+	Message *validator.WeatherMessage
+}
+
+// outOfRange generates values for a field outside its valid range
+type outOfRange struct {
+	field  string
+	values func() gopter.Gen
+	set    func(msg *validator.WeatherMessage, value float64)
+}
+
+// outOfRangeFields covers the ranges the default validator options check, so
+// weather.temperature, checked only with RejectImplausibleTemperature, isn't
+// here
+var outOfRangeFields = []outOfRange{
+	{
+		field:  "location.latitude",
+		values: func() gopter.Gen { return gen.OneGenOf(gen.Float64Range(-180, -90.01), gen.Float64Range(90.01, 180)) },
+		set:    func(msg *validator.WeatherMessage, value float64) { msg.Location.Latitude = value },
+	},
+	{
+		field:  "location.longitude",
+		values: func() gopter.Gen { return gen.OneGenOf(gen.Float64Range(-360, -180.01), gen.Float64Range(180.01, 360)) },
+		set:    func(msg *validator.WeatherMessage, value float64) { msg.Location.Longitude = value },
+	},
+	{
+		field:  "weather.humidity",
+		values: func() gopter.Gen { return gen.OneGenOf(gen.Float64Range(-100, -0.01), gen.Float64Range(100.01, 200)) },
+		set:    func(msg *validator.WeatherMessage, value float64) { msg.Weather.Humidity = value },
+	},
+	{
+		field:  "weather.windSpeed",
+		values: func() gopter.Gen { return gen.Float64Range(-100, -0.01) },
+		set:    func(msg *validator.WeatherMessage, value float64) { msg.Weather.WindSpeed = value },
+	},
+	{
+		field:  "weather.windDirection",
+		values: func() gopter.Gen { return gen.OneGenOf(gen.Float64Range(-360, -0.01), gen.Float64Range(360.01, 720)) },
+		set:    func(msg *validator.WeatherMessage, value float64) { msg.Weather.WindDirection = &value },
+	},
+	{
+		field:  "weather.rainProbability",
+		values: func() gopter.Gen { return gen.OneGenOf(gen.Float64Range(-100, -0.01), gen.Float64Range(100.01, 200)) },
+		set:    func(msg *validator.WeatherMessage, value float64) { msg.Weather.RainProbability = &value },
+	},
+}
+
+// InvalidFields lists the fields InvalidMessage puts out of range
+func InvalidFields() []string {
+	fields := make([]string, len(outOfRangeFields))
+	for i, f := range outOfRangeFields {
+		fields[i] = f.field
+	}
+	return fields
+}
+
+// ValidMessage generates messages that pass validation with the default
+// validator options. Temperatures stay in the plausible range and conditions
+// never imply precipitation, so the messages also pass the optional
+// plausible-temperature and cross-field checks.
+func ValidMessage() gopter.Gen {
+	return gopter.CombineGens(
+		gen.AlphaString().SuchThat(func(s string) bool { return len(s) > 0 }),
+		gen.Float64Range(-90, 90),
+		gen.Float64Range(-180, 180),
+		gen.Float64Range(validator.MinPlausibleTemperature, validator.MaxPlausibleTemperature),
+		gen.Float64Range(0, 100),
+		gen.Float64Range(0, 200),
+		gen.Float64Range(0, 100),
+		gen.OneConstOf("sunny", "cloudy", "partly_cloudy"),
+		gen.OneConstOf("open-meteo", "openweather"),
+	).Map(func(values []interface{}) *validator.WeatherMessage {
+		rainProbability := values[6].(float64)
+		return &validator.WeatherMessage{
+			Timestamp: "2025-12-03T14:30:00Z",
+			Location: validator.Location{
+				City:      values[0].(string),
+				Latitude:  values[1].(float64),
+				Longitude: values[2].(float64),
+			},
+			Weather: validator.Weather{
+				Temperature:     values[3].(float64),
+				Humidity:        values[4].(float64),
+				WindSpeed:       values[5].(float64),
+				Condition:       values[7].(string),
+				RainProbability: &rainProbability,
+			},
+			Source: values[8].(string),
+		}
+	})
+}
+
+// InvalidMessage generates Invalid values: a valid message with one of
+// InvalidFields, picked at random, out of range
+func InvalidMessage() gopter.Gen {
+	gens := make([]gopter.Gen, len(outOfRangeFields))
+	for i, f := range outOfRangeFields {
+		gens[i] = invalidMessage(f)
+	}
+	return gen.OneGenOf(gens...)
+}
+
+// InvalidMessageFor generates Invalid values with field out of range. It
+// panics if field isn't one of InvalidFields.
+func InvalidMessageFor(field string) gopter.Gen {
+	for _, f := range outOfRangeFields {
+		if f.field == field {
+			return invalidMessage(f)
+		}
+	}
+	panic(fmt.Sprintf("testgen: no out-of-range generator for %q", field))
+}
+
+// JSON encodes msg as a queue message body
+func JSON(msg *validator.WeatherMessage) []byte {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		panic(fmt.Sprintf("testgen: marshal message: %v", err))
+	}
+	return data
+}
+
+func invalidMessage(f outOfRange) gopter.Gen {
+	return gopter.CombineGens(ValidMessage(), f.values()).Map(func(values []interface{}) Invalid {
+		msg := values[0].(*validator.WeatherMessage)
+		f.set(msg, values[1].(float64))
+		return Invalid{Field: f.field, Message: msg}
+	})
+}
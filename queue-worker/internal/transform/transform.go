@@ -0,0 +1,198 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Transformer rewrites a raw message body before it is validated, for
+// provider-specific fixups such as renamed fields or scaled units
+type Transformer interface {
+	Transform(raw []byte) ([]byte, error)
+}
+
+// Func adapts a function to the Transformer interface
+type Func func(raw []byte) ([]byte, error)
+
+// Transform implements Transformer
+func (f Func) Transform(raw []byte) ([]byte, error) {
+	return f(raw)
+}
+
+// Identity returns bodies unchanged; it is the default
+type Identity struct{}
+
+// Transform implements Transformer
+func (Identity) Transform(raw []byte) ([]byte, error) {
+	return raw, nil
+}
+
+// BySource applies the transformer registered for the message's source,
+// read from the body's "source" field, and Default (Identity when nil) for
+// other sources and bodies that aren't a JSON object, so validation reports
+// those
+type BySource struct {
+	Sources map[string]Transformer
+	Default Transformer
+}
+
+// Transform implements Transformer
+func (t BySource) Transform(raw []byte) ([]byte, error) {
+	var envelope struct {
+		Source string `json:"source"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err == nil {
+		if transformer, ok := t.Sources[envelope.Source]; ok {
+			return transformer.Transform(raw)
+		}
+	}
+	if t.Default == nil {
+		return raw, nil
+	}
+	return t.Default.Transform(raw)
+}
+
+// RenameFields moves the value at each dotted path key (e.g.
+// "weather.wind_speed") to the dotted path it maps to, creating objects
+// along the new path as needed. Paths missing from a body are skipped.
+type RenameFields map[string]string
+
+// Transform implements Transformer
+func (renames RenameFields) Transform(raw []byte) ([]byte, error) {
+	doc, err := decodeObject(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for from, to := range renames {
+		value, ok := take(doc, strings.Split(from, "."))
+		if !ok {
+			continue
+		}
+		if err := put(doc, strings.Split(to, "."), value); err != nil {
+			return nil, fmt.Errorf("rename %s to %s: %w", from, to, err)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// SnakeCase renames snake_case keys at any depth to the camelCase the
+// message format uses, e.g. wind_speed to windSpeed
+type SnakeCase struct{}
+
+// Transform implements Transformer
+func (SnakeCase) Transform(raw []byte) ([]byte, error) {
+	doc, err := decodeObject(raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(camelKeys(doc))
+}
+
+// CheckRenames returns an error naming a rename whose from or to path has an
+// empty segment
+func CheckRenames(renames map[string]string) error {
+	for from, to := range renames {
+		for _, path := range []string{from, to} {
+			for _, key := range strings.Split(path, ".") {
+				if key == "" {
+					return fmt.Errorf("invalid path %q in rename %s=%s", path, from, to)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ByName returns the transformer registered under name ("none" or
+// "snake_case"). RenameFields takes its renames, so it isn't registered.
+func ByName(name string) (Transformer, bool) {
+	switch name {
+	case "none":
+		return Identity{}, true
+	case "snake_case":
+		return SnakeCase{}, true
+	}
+	return nil, false
+}
+
+// decodeObject decodes a JSON object, keeping numbers as written
+func decodeObject(raw []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode body: %w", err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("decode body: not a JSON object")
+	}
+	return doc, nil
+}
+
+// take removes and returns the value at path
+func take(doc map[string]interface{}, path []string) (interface{}, bool) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := doc[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		doc = next
+	}
+	last := path[len(path)-1]
+	value, ok := doc[last]
+	delete(doc, last)
+	return value, ok
+}
+
+// put sets the value at path, creating missing objects along it
+func put(doc map[string]interface{}, path []string, value interface{}) error {
+	for _, key := range path[:len(path)-1] {
+		existing, present := doc[key]
+		if !present {
+			next := map[string]interface{}{}
+			doc[key] = next
+			doc = next
+			continue
+		}
+		next, ok := existing.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an object", key)
+		}
+		doc = next
+	}
+	doc[path[len(path)-1]] = value
+	return nil
+}
+
+// camelKeys returns value with the keys of every object camelCased
+func camelKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			renamed[camelCase(key)] = camelKeys(item)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = camelKeys(item)
+		}
+		return v
+	}
+	return value
+}
+
+// camelCase converts a snake_case name to camelCase
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
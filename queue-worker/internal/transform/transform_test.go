@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Unit tests for message transformers
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Expected JSON output, got %s: %v", data, err)
+	}
+	return doc
+}
+
+func TestRenameFields_MovesNestedField(t *testing.T) {
+	renames := RenameFields{
+		"weather.wind_speed": "weather.windSpeed",
+		"station":            "location.city",
+		"weather.missing":    "weather.other",
+	}
+
+	out, err := renames.Transform([]byte(`{"station":"Recife","weather":{"wind_speed":12.30,"humidity":80}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"location": map[string]interface{}{"city": "Recife"},
+		"weather":  map[string]interface{}{"windSpeed": 12.3, "humidity": 80.0},
+	}
+	if got := decode(t, out); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRenameFields_RejectsNonObjectBody(t *testing.T) {
+	for _, body := range []string{`not json`, `null`, `[1, 2]`} {
+		if _, err := (RenameFields{"a": "b"}).Transform([]byte(body)); err == nil {
+			t.Errorf("Expected an error for %s", body)
+		}
+	}
+}
+
+func TestSnakeCase_CamelCasesKeys(t *testing.T) {
+	out, err := SnakeCase{}.Transform([]byte(`{"weather":{"wind_speed":5,"rain_probability":30},"source":"openweather"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	weather := decode(t, out)["weather"].(map[string]interface{})
+	if weather["windSpeed"] != 5.0 || weather["rainProbability"] != 30.0 || weather["wind_speed"] != nil {
+		t.Errorf("Expected camelCase weather keys, got %v", weather)
+	}
+}
+
+func TestBySource_SelectsTransformerBySource(t *testing.T) {
+	failing := Func(func(raw []byte) ([]byte, error) { return nil, errors.New("boom") })
+	transformer := BySource{Sources: map[string]Transformer{
+		"openweather": RenameFields{"temp": "weather.temperature"},
+		"broken":      failing,
+	}}
+
+	out, err := transformer.Transform([]byte(`{"source":"openweather","temp":21}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decode(t, out)["weather"] == nil {
+		t.Errorf("Expected the openweather transformer applied, got %s", out)
+	}
+
+	body := []byte(`{"source":"open-meteo","temp":21}`)
+	if out, _ := transformer.Transform(body); string(out) != string(body) {
+		t.Errorf("Expected an unmapped source unchanged, got %s", out)
+	}
+	if out, _ := transformer.Transform([]byte(`not json`)); string(out) != "not json" {
+		t.Errorf("Expected a non-JSON body unchanged, got %s", out)
+	}
+	if _, err := transformer.Transform([]byte(`{"source":"broken"}`)); err == nil {
+		t.Error("Expected the failing transformer's error")
+	}
+}
+
+func TestByName(t *testing.T) {
+	if transformer, ok := ByName("snake_case"); !ok || transformer != (SnakeCase{}) {
+		t.Errorf("Expected snake_case transformer, got %v (ok=%v)", transformer, ok)
+	}
+	if _, ok := ByName("uppercase"); ok {
+		t.Error("Expected unknown transformer to be rejected")
+	}
+}
+
+func TestCheckRenames(t *testing.T) {
+	if err := CheckRenames(map[string]string{"temp": "weather.temperature"}); err != nil {
+		t.Errorf("Expected valid renames to pass, got %v", err)
+	}
+	for _, renames := range []map[string]string{{"": "weather.temperature"}, {"temp": "weather..temperature"}} {
+		if err := CheckRenames(renames); err == nil {
+			t.Errorf("Expected %v to be rejected", renames)
+		}
+	}
+}
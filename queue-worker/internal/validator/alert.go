@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"strings"
+	"time"
+)
+
+// Message types, from the optional "type" field. Messages without one are
+// readings.
+const (
+	TypeReading = "reading"
+	TypeAlert   = "alert"
+)
+
+// alertSeverities are the accepted Alert.Severity values
+var alertSeverities = map[string]bool{"minor": true, "moderate": true, "severe": true, "extreme": true}
+
+// Alert holds the fields a severe-weather alert adds to a reading. It is
+// embedded in WeatherMessage, so the fields sit at the top level of the JSON,
+// and is nil for messages that carry none of them.
+type Alert struct {
+	Severity  string `json:"severity,omitempty"`
+	Headline  string `json:"headline,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// IsAlert reports whether the message is a severe-weather alert
+func (msg *WeatherMessage) IsAlert() bool {
+	return msg != nil && msg.Type == TypeAlert
+}
+
+// alertErrors checks the fields an alert must carry on top of a reading's.
+// expiresAt must be RFC3339 and not precede the timestamp; like the timestamp
+// it is normalized to RFC3339Nano in UTC.
+func alertErrors(msg *WeatherMessage) ValidationErrors {
+	alert := msg.Alert
+	if alert == nil {
+		alert = &Alert{}
+	}
+
+	var errs ValidationErrors
+	if alert.Severity == "" {
+		errs = append(errs, ValidationError{Field: "severity", Code: CodeRequired, Message: "required field is missing"})
+	} else if !alertSeverities[strings.ToLower(alert.Severity)] {
+		errs = append(errs, ValidationError{Field: "severity", Code: CodeInvalidFormat, Message: "must be one of minor, moderate, severe, extreme"})
+	}
+	if strings.TrimSpace(alert.Headline) == "" {
+		errs = append(errs, ValidationError{Field: "headline", Code: CodeRequired, Message: "required field is missing"})
+	}
+
+	if alert.ExpiresAt == "" {
+		errs = append(errs, ValidationError{Field: "expiresAt", Code: CodeRequired, Message: "required field is missing"})
+		return errs
+	}
+	expiresAt, err := time.Parse(time.RFC3339, alert.ExpiresAt)
+	if err != nil {
+		return append(errs, ValidationError{Field: "expiresAt", Code: CodeInvalidFormat, Message: "invalid format, expected rfc3339"})
+	}
+	if issued, err := time.Parse(time.RFC3339Nano, msg.Timestamp); err == nil && expiresAt.Before(issued) {
+		return append(errs, ValidationError{Field: "expiresAt", Code: CodeOutOfRange, Message: "must not be before timestamp"})
+	}
+	alert.ExpiresAt = expiresAt.UTC().Format(time.RFC3339Nano)
+	return errs
+}
@@ -0,0 +1,96 @@
+package validator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Unit tests for alert messages
+
+// createAlertMessage returns a valid alert with the given fields overridden;
+// a nil value removes the field
+func createAlertMessage(overrides map[string]interface{}) []byte {
+	msg := map[string]interface{}{
+		"type":      "alert",
+		"timestamp": "2025-12-03T14:30:00Z",
+		"location":  map[string]interface{}{"city": "Porto Alegre", "latitude": -30.03, "longitude": -51.23},
+		"weather": map[string]interface{}{
+			"temperature": 18, "humidity": 95, "windSpeed": 22, "condition": "storm", "rainProbability": 90,
+		},
+		"source":    "inmet",
+		"severity":  "severe",
+		"headline":  "Severe thunderstorm warning",
+		"expiresAt": "2025-12-03T21:00:00-03:00",
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(msg, key)
+		} else {
+			msg[key] = value
+		}
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidateMessage_Alert(t *testing.T) {
+	msg, err := ValidateMessage(createAlertMessage(nil))
+	if err != nil {
+		t.Fatalf("Expected alert to be valid, got %v", err)
+	}
+	if !msg.IsAlert() || msg.Alert == nil || msg.Severity != "severe" || msg.Headline != "Severe thunderstorm warning" {
+		t.Errorf("Expected alert fields parsed, got %+v", msg.Alert)
+	}
+	if msg.ExpiresAt != "2025-12-04T00:00:00Z" {
+		t.Errorf("Expected expiresAt normalized to UTC, got %s", msg.ExpiresAt)
+	}
+}
+
+func TestValidateMessage_AlertErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]interface{}
+		field     string
+		code      string
+	}{
+		{"missing severity", map[string]interface{}{"severity": nil}, "severity", CodeRequired},
+		{"unknown severity", map[string]interface{}{"severity": "apocalyptic"}, "severity", CodeInvalidFormat},
+		{"missing headline", map[string]interface{}{"headline": " "}, "headline", CodeRequired},
+		{"missing expiresAt", map[string]interface{}{"expiresAt": nil}, "expiresAt", CodeRequired},
+		{"invalid expiresAt", map[string]interface{}{"expiresAt": "tonight"}, "expiresAt", CodeInvalidFormat},
+		{"expired before issued", map[string]interface{}{"expiresAt": "2025-12-03T14:00:00Z"}, "expiresAt", CodeOutOfRange},
+		{"unknown type", map[string]interface{}{"type": "forecast"}, "type", CodeInvalidFormat},
+		{"reading fields still checked", map[string]interface{}{"location": map[string]interface{}{"latitude": 10, "longitude": 10}}, "location.city", CodeRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateMessage(createAlertMessage(tt.overrides))
+			validationErr, ok := err.(ValidationError)
+			if !ok || validationErr.Field != tt.field || validationErr.Code != tt.code {
+				t.Errorf("Expected %s error on %s, got %v", tt.code, tt.field, err)
+			}
+		})
+	}
+}
+
+func TestValidateMessage_ReadingWithoutType(t *testing.T) {
+	for _, overrides := range []map[string]interface{}{
+		{"type": nil, "severity": nil, "headline": nil, "expiresAt": nil},
+		{"type": "reading", "severity": nil, "headline": nil, "expiresAt": nil},
+	} {
+		msg, err := ValidateMessage(createAlertMessage(overrides))
+		if err != nil {
+			t.Fatalf("Expected reading to be valid, got %v", err)
+		}
+		if msg.IsAlert() || msg.Alert != nil {
+			t.Errorf("Expected a plain reading, got %+v", msg)
+		}
+		data, _ := json.Marshal(msg)
+		var fields map[string]interface{}
+		json.Unmarshal(data, &fields)
+		if _, ok := fields["severity"]; ok {
+			t.Errorf("Expected no alert fields sent for a reading, got %s", data)
+		}
+	}
+}
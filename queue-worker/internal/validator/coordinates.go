@@ -0,0 +1,23 @@
+package validator
+
+import (
+	"math"
+)
+
+// DefaultCoordinateDecimals keeps coordinates to about 0.1 m, well beyond
+// what weather data needs
+const DefaultCoordinateDecimals = 6
+
+// MaxCoordinateDecimals is the most decimals a float64 coordinate holds
+// meaningfully
+const MaxCoordinateDecimals = 15
+
+// roundCoordinates rounds the message's latitude and longitude to the
+// configured number of decimals, dropping false precision some producers send
+func (v *Validator) roundCoordinates(msg *WeatherMessage) {
+	if v.coordinateScale == 0 {
+		return
+	}
+	msg.Location.Latitude = math.Round(msg.Location.Latitude*v.coordinateScale) / v.coordinateScale
+	msg.Location.Longitude = math.Round(msg.Location.Longitude*v.coordinateScale) / v.coordinateScale
+}
@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Unit tests for rounding coordinates
+
+func createCoordinateMessage(latitude, longitude float64) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	location := msg["location"].(map[string]interface{})
+	location["latitude"] = latitude
+	location["longitude"] = longitude
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_RoundsCoordinatesToConfiguredDecimals(t *testing.T) {
+	tests := []struct {
+		name      string
+		decimals  int
+		latitude  float64
+		longitude float64
+		wantLat   float64
+		wantLon   float64
+	}{
+		{"default precision", DefaultCoordinateDecimals, -23.55052012345678, -46.63330812345678, -23.55052, -46.633308},
+		{"negative rounds away from zero", 2, -8.04576, -34.87712, -8.05, -34.88},
+		{"positive", 3, 2.81944444, 60.02345678, 2.819, 60.023},
+		{"whole degrees", 0, -15.7939, 47.5, -16, 48},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New(Options{RoundCoordinates: true, CoordinateDecimals: tt.decimals})
+
+			msg, err := v.ValidateMessage(createCoordinateMessage(tt.latitude, tt.longitude))
+			if err != nil {
+				t.Fatalf("Expected valid message, got %v", err)
+			}
+			if msg.Location.Latitude != tt.wantLat || msg.Location.Longitude != tt.wantLon {
+				t.Errorf("Expected %v,%v, got %v,%v", tt.wantLat, tt.wantLon, msg.Location.Latitude, msg.Location.Longitude)
+			}
+		})
+	}
+}
+
+func TestValidator_KeepsCoordinatesWhenRoundingDisabled(t *testing.T) {
+	v := New(Options{CoordinateDecimals: 2})
+
+	msg, err := v.ValidateMessage(createCoordinateMessage(-23.55052012345678, -46.63330812345678))
+	if err != nil {
+		t.Fatalf("Expected valid message, got %v", err)
+	}
+	if msg.Location.Latitude != -23.55052012345678 || msg.Location.Longitude != -46.63330812345678 {
+		t.Errorf("Expected coordinates unchanged, got %v,%v", msg.Location.Latitude, msg.Location.Longitude)
+	}
+}
+
+func TestValidator_RoundsCoordinatesWhenCollectingAllErrors(t *testing.T) {
+	v := New(Options{RoundCoordinates: true, CoordinateDecimals: 1})
+
+	msg, err := v.ValidateMessageAll(createCoordinateMessage(-8.04576, -34.87712))
+	if err != nil {
+		t.Fatalf("Expected valid message, got %v", err)
+	}
+	if msg.Location.Latitude != -8 || msg.Location.Longitude != -34.9 {
+		t.Errorf("Expected -8,-34.9, got %v,%v", msg.Location.Latitude, msg.Location.Longitude)
+	}
+}
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+// CoordinateTolerance is the largest latitude or longitude difference, in
+// degrees, for which Equal treats two locations as the same. 1e-6 degrees is
+// about 11 cm at the equator, well below what producers can resolve, so it
+// only absorbs float formatting differences between sources.
+const CoordinateTolerance = 1e-6
+
+// Canonical returns a normalized copy of msg: text fields are trimmed, the
+// condition is lowercased as validation compares it case-insensitively, and a
+// parseable RFC3339 timestamp is rewritten as RFC3339Nano in UTC. Numeric
+// fields are copied unchanged.
+func (msg *WeatherMessage) Canonical() *WeatherMessage {
+	canonical := *msg
+	canonical.Timestamp = strings.TrimSpace(msg.Timestamp)
+	if t, err := time.Parse(time.RFC3339Nano, canonical.Timestamp); err == nil {
+		canonical.Timestamp = t.UTC().Format(time.RFC3339Nano)
+	}
+	canonical.Location.City = strings.TrimSpace(msg.Location.City)
+	canonical.Location.State = strings.TrimSpace(msg.Location.State)
+	canonical.Weather.Condition = strings.ToLower(strings.TrimSpace(msg.Weather.Condition))
+	canonical.Source = strings.TrimSpace(msg.Source)
+	if msg.Weather.WindDirection != nil {
+		windDirection := *msg.Weather.WindDirection
+		canonical.Weather.WindDirection = &windDirection
+	}
+	if msg.Weather.RainProbability != nil {
+		rainProbability := *msg.Weather.RainProbability
+		canonical.Weather.RainProbability = &rainProbability
+	}
+	return &canonical
+}
+
+// CanonicalJSON serializes msg normalized with Canonical, with object keys
+// sorted and numbers in Go's shortest float form, so equivalent messages give
+// byte-identical output whatever their field order or formatting
+func (msg *WeatherMessage) CanonicalJSON() ([]byte, error) {
+	data, err := json.Marshal(msg.Canonical())
+	if err != nil {
+		return nil, err
+	}
+
+	// Decoding into maps and encoding again sorts the keys at every level
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// IdempotencyKey identifies the reading msg describes: the hex SHA-256 of its
+// CanonicalJSON, so redeliveries and reformatted copies share a key
+func (msg *WeatherMessage) IdempotencyKey() (string, error) {
+	data, err := msg.CanonicalJSON()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Equal reports whether msg and other describe the same reading once
+// normalized with Canonical. Coordinates match within CoordinateTolerance;
+// all other numeric fields must match exactly.
+func (msg *WeatherMessage) Equal(other *WeatherMessage) bool {
+	if msg == nil || other == nil {
+		return msg == other
+	}
+
+	a, b := msg.Canonical(), other.Canonical()
+	return a.Timestamp == b.Timestamp &&
+		a.Location.City == b.Location.City &&
+		a.Location.State == b.Location.State &&
+		math.Abs(a.Location.Latitude-b.Location.Latitude) <= CoordinateTolerance &&
+		math.Abs(a.Location.Longitude-b.Location.Longitude) <= CoordinateTolerance &&
+		a.Weather.Temperature == b.Weather.Temperature &&
+		a.Weather.Humidity == b.Weather.Humidity &&
+		a.Weather.WindSpeed == b.Weather.WindSpeed &&
+		a.Weather.Condition == b.Weather.Condition &&
+		equalOptional(a.Weather.RainProbability, b.Weather.RainProbability) &&
+		equalOptional(a.Weather.WindDirection, b.Weather.WindDirection) &&
+		a.Source == b.Source
+}
+
+// equalOptional compares optional values that are nil when not reported
+func equalOptional(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
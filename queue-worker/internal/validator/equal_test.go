@@ -0,0 +1,157 @@
+package validator
+
+import (
+	"testing"
+)
+
+// Unit tests for message normalization and equality
+
+func createEqualTestMessage() *WeatherMessage {
+	windDirection := 180.0
+	rainProbability := 30.0
+	return &WeatherMessage{
+		Timestamp: "2025-12-03T14:30:00Z",
+		Location: Location{
+			City:      "São Paulo",
+			Latitude:  -23.5505,
+			Longitude: -46.6333,
+		},
+		Weather: Weather{
+			Temperature:     28.5,
+			Humidity:        65.0,
+			WindSpeed:       12.3,
+			Condition:       "partly_cloudy",
+			RainProbability: &rainProbability,
+			WindDirection:   &windDirection,
+		},
+		Source: "open-meteo",
+	}
+}
+
+func TestCanonical_NormalizesTextAndTimestamp(t *testing.T) {
+	msg := createEqualTestMessage()
+	msg.Timestamp = "2025-12-03T11:30:00-03:00"
+	msg.Location.City = "  São Paulo "
+	msg.Source = "open-meteo\n"
+
+	canonical := msg.Canonical()
+
+	if canonical.Timestamp != "2025-12-03T14:30:00Z" {
+		t.Errorf("Expected UTC timestamp, got %s", canonical.Timestamp)
+	}
+	if canonical.Location.City != "São Paulo" || canonical.Source != "open-meteo" {
+		t.Errorf("Expected trimmed text fields, got %q and %q", canonical.Location.City, canonical.Source)
+	}
+	if msg.Location.City != "  São Paulo " {
+		t.Error("Expected Canonical not to modify the original message")
+	}
+	if canonical.Weather.WindDirection == msg.Weather.WindDirection {
+		t.Error("Expected Canonical to copy the wind direction")
+	}
+}
+
+func TestEqual_IgnoresFormatting(t *testing.T) {
+	a := createEqualTestMessage()
+	b := createEqualTestMessage()
+	b.Timestamp = "2025-12-03T11:30:00.000-03:00"
+	b.Location.City = "São Paulo "
+
+	if !a.Equal(b) {
+		t.Error("Expected messages differing only in formatting to be equal")
+	}
+}
+
+func TestEqual_CoordinateTolerance(t *testing.T) {
+	a := createEqualTestMessage()
+
+	within := createEqualTestMessage()
+	within.Location.Latitude += CoordinateTolerance / 2
+	if !a.Equal(within) {
+		t.Error("Expected coordinates within tolerance to be equal")
+	}
+
+	beyond := createEqualTestMessage()
+	beyond.Location.Longitude += CoordinateTolerance * 10
+	if a.Equal(beyond) {
+		t.Error("Expected coordinates beyond tolerance to differ")
+	}
+}
+
+func TestEqual_NumericFieldsExact(t *testing.T) {
+	a := createEqualTestMessage()
+
+	b := createEqualTestMessage()
+	b.Weather.Temperature += 1e-9
+	if a.Equal(b) {
+		t.Error("Expected any temperature difference to make messages unequal")
+	}
+
+	c := createEqualTestMessage()
+	c.Weather.WindDirection = nil
+	if a.Equal(c) {
+		t.Error("Expected missing wind direction to differ from a reported one")
+	}
+}
+
+func TestEqual_Nil(t *testing.T) {
+	var a *WeatherMessage
+	if !a.Equal(nil) {
+		t.Error("Expected nil messages to be equal")
+	}
+	if createEqualTestMessage().Equal(nil) {
+		t.Error("Expected a message not to equal nil")
+	}
+}
+
+func TestCanonicalJSON_EquivalentInputsMatch(t *testing.T) {
+	a, err := ValidateMessage([]byte(`{"timestamp":"2025-12-03T14:30:00Z","location":{"city":"São Paulo","latitude":-23.5505,"longitude":-46.6333},"weather":{"temperature":28.5,"humidity":65,"windSpeed":12.3,"condition":"rainy","rainProbability":80},"source":"open-meteo"}`))
+	if err != nil {
+		t.Fatalf("Expected valid message, got: %v", err)
+	}
+	b, err := ValidateMessage([]byte(`{"source":"open-meteo","weather":{"rainProbability":80.0,"condition":"Rainy","windSpeed":12.30,"humidity":65.0,"temperature":28.50},"location":{"longitude":-46.6333,"latitude":-23.5505,"city":" São Paulo "},"timestamp":"2025-12-03T11:30:00-03:00"}`))
+	if err != nil {
+		t.Fatalf("Expected valid message, got: %v", err)
+	}
+
+	jsonA, err := a.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("Expected canonical JSON, got: %v", err)
+	}
+	jsonB, err := b.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("Expected canonical JSON, got: %v", err)
+	}
+	if string(jsonA) != string(jsonB) {
+		t.Errorf("Expected identical canonical JSON, got\n%s\n%s", jsonA, jsonB)
+	}
+
+	keyA, _ := a.IdempotencyKey()
+	keyB, _ := b.IdempotencyKey()
+	if keyA != keyB || len(keyA) != 64 {
+		t.Errorf("Expected matching SHA-256 keys, got %s and %s", keyA, keyB)
+	}
+}
+
+func TestCanonicalJSON_SortsKeys(t *testing.T) {
+	data, err := createEqualTestMessage().CanonicalJSON()
+	if err != nil {
+		t.Fatalf("Expected canonical JSON, got: %v", err)
+	}
+
+	want := `{"location":{"city":"São Paulo","latitude":-23.5505,"longitude":-46.6333},"source":"open-meteo","timestamp":"2025-12-03T14:30:00Z","weather":{"condition":"partly_cloudy","humidity":65,"rainProbability":30,"temperature":28.5,"windDirection":180,"windSpeed":12.3}}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func TestIdempotencyKey_DiffersForDifferentReadings(t *testing.T) {
+	a := createEqualTestMessage()
+	b := createEqualTestMessage()
+	b.Weather.Temperature = 29
+
+	keyA, _ := a.IdempotencyKey()
+	keyB, _ := b.IdempotencyKey()
+	if keyA == keyB {
+		t.Error("Expected different readings to have different keys")
+	}
+}
@@ -0,0 +1,33 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Fingerprint identifies the shape of a raw JSON message: a short hash of
+// the sorted dotted paths of its non-null fields, top-level and nested.
+// Messages with the same fields share a fingerprint whatever their values,
+// so a new fingerprint means a producer added or dropped fields. The paths
+// are returned too, for logging a shape when it is first seen.
+func Fingerprint(data []byte) (string, []string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return "", nil, errors.New("invalid JSON format: " + err.Error())
+	}
+
+	present := make(map[string]bool)
+	collectPresentFields(raw, "", present)
+	paths := make([]string, 0, len(present))
+	for path := range present {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	sum := sha256.Sum256([]byte(strings.Join(paths, "\n")))
+	return hex.EncodeToString(sum[:8]), paths, nil
+}
@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Unit tests for message shape fingerprints
+
+func TestFingerprint_IgnoresValuesAndKeyOrder(t *testing.T) {
+	a, _, err := Fingerprint([]byte(`{"source":"a","location":{"city":"Recife","latitude":1}}`))
+	if err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	b, _, _ := Fingerprint([]byte(`{"location":{"latitude":-8.05,"city":"Natal"},"source":"b"}`))
+	if a != b {
+		t.Errorf("Expected the same fingerprint for the same fields, got %s and %s", a, b)
+	}
+}
+
+func TestFingerprint_ChangesWithFields(t *testing.T) {
+	a, _, _ := Fingerprint([]byte(`{"source":"a","location":{"city":"Recife"}}`))
+	b, _, _ := Fingerprint([]byte(`{"source":"a","location":{"city":"Recife","state":"PE"}}`))
+	c, _, _ := Fingerprint([]byte(`{"source":"a","location":{"city":"Recife"},"extra":null}`))
+
+	if a == b {
+		t.Error("Expected a nested field to change the fingerprint")
+	}
+	if a != c {
+		t.Error("Expected null fields to be ignored")
+	}
+}
+
+func TestFingerprint_ReturnsSortedPaths(t *testing.T) {
+	_, paths, _ := Fingerprint([]byte(`{"source":"a","location":{"city":"Recife"}}`))
+
+	expected := []string{"location", "location.city", "source"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}
+
+func TestFingerprint_RejectsInvalidJSON(t *testing.T) {
+	if _, _, err := Fingerprint([]byte(`not json`)); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
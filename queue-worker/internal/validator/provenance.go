@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckPublisher rejects a message whose source doesn't match publisher, the
+// identity its broker-authenticated publisher put on the delivery, to catch
+// spoofed or misrouted messages. A delivery without one is rejected too.
+// It does nothing unless Options.RequireSourceMatchesPublisher is set.
+func (v *Validator) CheckPublisher(msg *WeatherMessage, publisher string) error {
+	if !v.sourceMatchesPublisher {
+		return nil
+	}
+
+	publisher = strings.TrimSpace(publisher)
+	if publisher == "" {
+		return ValidationError{Field: "source", Code: CodeInconsistent, Message: "delivery carries no publisher id to match"}
+	}
+	if !strings.EqualFold(strings.TrimSpace(msg.Source), publisher) {
+		return ValidationError{Field: "source", Code: CodeInconsistent, Message: fmt.Sprintf("does not match publisher %q", publisher)}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"testing"
+)
+
+// Unit tests for matching the source to the delivery's publisher
+
+func TestCheckPublisher(t *testing.T) {
+	v := New(Options{RequireSourceMatchesPublisher: true})
+
+	tests := []struct {
+		name      string
+		publisher string
+		valid     bool
+	}{
+		{"matching publisher", "open-meteo", true},
+		{"case-insensitive match", "Open-Meteo", true},
+		{"mismatched publisher", "openweather", false},
+		{"missing publisher", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.CheckPublisher(createEqualTestMessage(), tt.publisher)
+			if tt.valid {
+				if err != nil {
+					t.Errorf("Expected match, got %v", err)
+				}
+				return
+			}
+			validationErr, ok := err.(ValidationError)
+			if !ok || validationErr.Field != "source" || validationErr.Code != CodeInconsistent {
+				t.Errorf("Expected inconsistent source error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckPublisher_OffByDefault(t *testing.T) {
+	if err := New(Options{}).CheckPublisher(createEqualTestMessage(), "openweather"); err != nil {
+		t.Errorf("Expected no check by default, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema modes for Options.SchemaMode
+const (
+	// SchemaModeAdditional checks raw messages against the registered schema
+	// after the built-in rules
+	SchemaModeAdditional = "additional"
+	// SchemaModeReplace checks raw messages only against the registered
+	// schema, skipping the built-in rules
+	SchemaModeReplace = "replace"
+)
+
+// Schema error codes
+const (
+	// CodeSchema means the message doesn't match its registered schema
+	CodeSchema = "schema"
+	// CodeSchemaUnavailable means the schema couldn't be fetched and the
+	// validator fails closed; the message itself may be fine
+	CodeSchemaUnavailable = "schema_unavailable"
+)
+
+// CheckSchemaMode returns an error if mode isn't a known schema mode
+func CheckSchemaMode(mode string) error {
+	switch mode {
+	case "", SchemaModeAdditional, SchemaModeReplace:
+		return nil
+	}
+	return fmt.Errorf("unknown schema mode %q (expected %s or %s)", mode, SchemaModeAdditional, SchemaModeReplace)
+}
+
+// rawMessageErrors applies the built-in rules and the registered schema to a
+// raw message, as configured by the schema mode
+func (v *Validator) rawMessageErrors(data []byte, msg *WeatherMessage, present map[string]bool, firstOnly bool) ValidationErrors {
+	var errs ValidationErrors
+	if v.schemas != nil && v.schemaMode == SchemaModeReplace {
+		v.applyDefaults(msg)
+	} else {
+		errs = v.fieldErrors(msg, present, firstOnly)
+	}
+	if v.schemas == nil || (firstOnly && len(errs) > 0) {
+		return errs
+	}
+	return append(errs, v.schemaErrors(data, msg, firstOnly)...)
+}
+
+// schemaErrors checks a raw message against the schema registered for its
+// source and schema version. A schema that can't be fetched passes the
+// message when failing open and rejects it with CodeSchemaUnavailable
+// otherwise.
+func (v *Validator) schemaErrors(data []byte, msg *WeatherMessage, firstOnly bool) ValidationErrors {
+	s, err := v.schemas.Schema(strings.TrimSpace(msg.Source), msg.Version())
+	if err != nil {
+		if v.schemaFailOpen {
+			return nil
+		}
+		return ValidationErrors{{Field: "schema", Code: CodeSchemaUnavailable, Message: err.Error()}}
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return ValidationErrors{{Field: "body", Code: CodeSchema, Message: "invalid JSON format"}}
+	}
+
+	var errs ValidationErrors
+	for _, violation := range s.Validate(doc) {
+		field := violation.Path
+		if field == "" {
+			field = "body"
+		}
+		errs = append(errs, ValidationError{Field: field, Code: CodeSchema, Message: violation.Message})
+		if firstOnly {
+			break
+		}
+	}
+	return errs
+}
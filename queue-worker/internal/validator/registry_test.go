@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"queue-worker/internal/schema"
+)
+
+// Unit tests for validating against registered schemas
+
+// fakeSchemas serves one schema for every source and version, or an error
+type fakeSchemas struct {
+	schema  *schema.Schema
+	err     error
+	source  string
+	version int
+}
+
+func (f *fakeSchemas) Schema(source string, version int) (*schema.Schema, error) {
+	f.source, f.version = source, version
+	return f.schema, f.err
+}
+
+// requireState is a registered schema requiring location.state
+func requireState(t *testing.T) *fakeSchemas {
+	t.Helper()
+	s, err := schema.Parse([]byte(`{"type":"object","properties":{"location":{"type":"object","required":["state"]}}}`))
+	if err != nil {
+		t.Fatalf("Expected schema to parse, got: %v", err)
+	}
+	return &fakeSchemas{schema: s}
+}
+
+func TestValidator_SchemaAdditionalToBuiltInRules(t *testing.T) {
+	schemas := requireState(t)
+	v := New(Options{SchemaProvider: schemas})
+
+	_, err := v.ValidateMessage(createVersionedMessage(2))
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "location.state" || validationErr.Code != CodeSchema {
+		t.Errorf("Expected location.state schema error, got %v", err)
+	}
+	if schemas.source != "open-meteo" || schemas.version != 2 {
+		t.Errorf("Expected lookup by source and version, got %s v%d", schemas.source, schemas.version)
+	}
+
+	// Built-in rules still run first
+	_, err = v.ValidateMessage(createCoordinatesMessage(91, 0))
+	if !errors.As(err, &validationErr) || validationErr.Field != "location.latitude" {
+		t.Errorf("Expected built-in latitude error, got %v", err)
+	}
+}
+
+func TestValidator_SchemaReplacesBuiltInRules(t *testing.T) {
+	s, _ := schema.Parse([]byte(`{"type":"object"}`))
+	v := New(Options{SchemaProvider: &fakeSchemas{schema: s}, SchemaMode: SchemaModeReplace})
+
+	if _, err := v.ValidateMessage(createCoordinatesMessage(91, 0)); err != nil {
+		t.Errorf("Expected only the schema to apply, got %v", err)
+	}
+}
+
+func TestValidator_SchemaAllCollectsEveryError(t *testing.T) {
+	v := New(Options{SchemaProvider: requireState(t)})
+
+	_, err := v.ValidateMessageAll(createCoordinatesMessage(91, 0))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Field != "location.latitude" || errs[1].Field != "location.state" {
+		t.Errorf("Expected built-in and schema errors, got %v", err)
+	}
+}
+
+func TestValidator_SchemaUnavailable(t *testing.T) {
+	unavailable := &fakeSchemas{err: errors.New("registry down")}
+
+	open := New(Options{SchemaProvider: unavailable, SchemaFailOpen: true})
+	if _, err := open.ValidateMessage(createVersionedMessage(1)); err != nil {
+		t.Errorf("Expected fail-open to accept the message, got %v", err)
+	}
+
+	closed := New(Options{SchemaProvider: unavailable})
+	_, err := closed.ValidateMessage(createVersionedMessage(1))
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != CodeSchemaUnavailable {
+		t.Errorf("Expected schema_unavailable when failing closed, got %v", err)
+	}
+}
+
+func TestCheckSchemaMode(t *testing.T) {
+	for _, mode := range []string{"", SchemaModeAdditional, SchemaModeReplace} {
+		if err := CheckSchemaMode(mode); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", mode, err)
+		}
+	}
+	if err := CheckSchemaMode("strict"); err == nil {
+		t.Error("Expected unknown mode to fail")
+	}
+}
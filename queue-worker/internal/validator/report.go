@@ -0,0 +1,32 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Report validates data against v and writes a human-readable result to w:
+// the parsed message as indented JSON, or every failed rule with its field
+// and code. It reports whether the message is valid.
+func (v *Validator) Report(w io.Writer, data []byte) bool {
+	msg, err := v.ValidateMessageAll(data)
+	if err == nil {
+		pretty, _ := json.MarshalIndent(msg, "", "  ")
+		fmt.Fprintf(w, "valid message:\n%s\n", pretty)
+		return true
+	}
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		fmt.Fprintf(w, "invalid message: %s\n", err)
+		return false
+	}
+
+	fmt.Fprintf(w, "invalid message: %d validation error(s)\n", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(w, "  %s [%s]: %s\n", e.Field, e.Code, e.Message)
+	}
+	return false
+}
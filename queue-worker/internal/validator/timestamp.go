@@ -0,0 +1,118 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamp format names accepted in Options.TimestampFormats
+const (
+	FormatRFC3339     = "rfc3339"
+	FormatRFC3339Nano = "rfc3339nano"
+	FormatUnix        = "unix"
+	FormatUnixMillis  = "unix_ms"
+)
+
+// DefaultTimestampFormats is the default list of accepted timestamp formats
+var DefaultTimestampFormats = []string{FormatRFC3339}
+
+// timestampParsers maps each format name to its parser
+var timestampParsers = map[string]func(string) (time.Time, error){
+	FormatRFC3339: func(value string) (time.Time, error) {
+		return time.Parse(time.RFC3339, value)
+	},
+	FormatRFC3339Nano: func(value string) (time.Time, error) {
+		return time.Parse(time.RFC3339Nano, value)
+	},
+	FormatUnix: func(value string) (time.Time, error) {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(seconds, 0), nil
+	},
+	FormatUnixMillis: func(value string) (time.Time, error) {
+		millis, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(millis), nil
+	},
+}
+
+// zonedFormats are the formats whose values carry a zone offset; epoch
+// timestamps are always UTC
+var zonedFormats = map[string]bool{FormatRFC3339: true, FormatRFC3339Nano: true}
+
+// CheckTimestampFormats returns an error naming the first unsupported format
+func CheckTimestampFormats(formats []string) error {
+	for _, format := range formats {
+		if _, ok := timestampParsers[format]; !ok {
+			return fmt.Errorf("unknown timestamp format %q", format)
+		}
+	}
+	return nil
+}
+
+// ParseTimestamp parses a message timestamp with the validator's accepted
+// formats
+func (v *Validator) ParseTimestamp(value string) (time.Time, bool) {
+	return v.parseTimestamp(value)
+}
+
+// parseTimestamp tries each format in order and returns the first match
+func (v *Validator) parseTimestamp(value string) (time.Time, bool) {
+	t, _, ok := v.parseTimestampFormat(value)
+	return t, ok
+}
+
+// parseTimestampFormat is parseTimestamp, also returning the matching format
+func (v *Validator) parseTimestampFormat(value string) (time.Time, string, bool) {
+	for _, format := range v.timestampFormats {
+		if t, err := timestampParsers[format](value); err == nil {
+			return t, format, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// nonUTCOffset reports whether a timestamp parsed with format was written
+// with an offset other than UTC
+func nonUTCOffset(t time.Time, format string) bool {
+	if !zonedFormats[format] {
+		return false
+	}
+	_, offset := t.Zone()
+	return offset != 0
+}
+
+// UnmarshalJSON accepts the timestamp as a JSON string or, for epoch
+// formats, a JSON number, which is kept as its decimal text
+func (msg *WeatherMessage) UnmarshalJSON(data []byte) error {
+	type plain WeatherMessage
+	aux := struct {
+		*plain
+		Timestamp json.RawMessage `json:"timestamp"`
+	}{plain: (*plain)(msg)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	raw := strings.TrimSpace(string(aux.Timestamp))
+	switch {
+	case raw == "" || raw == "null":
+		msg.Timestamp = ""
+	case raw[0] == '"':
+		return json.Unmarshal(aux.Timestamp, &msg.Timestamp)
+	default:
+		var number json.Number
+		if err := json.Unmarshal(aux.Timestamp, &number); err != nil {
+			return fmt.Errorf("timestamp must be a string or number: %w", err)
+		}
+		msg.Timestamp = number.String()
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package validator
+
+import (
+	"testing"
+)
+
+// Unit tests for timestamp zones and UTC normalization
+
+func TestValidator_TimestampZones(t *testing.T) {
+	tests := []struct {
+		name       string
+		timestamp  interface{}
+		requireUTC bool
+		expected   string // normalized timestamp, empty if rejected
+	}{
+		{"utc", "2025-12-03T14:30:00Z", false, "2025-12-03T14:30:00Z"},
+		{"zero offset", "2025-12-03T14:30:00+00:00", false, "2025-12-03T14:30:00Z"},
+		{"zoned converted to utc", "2025-12-03T11:30:00-03:00", false, "2025-12-03T14:30:00Z"},
+		{"zone-less rejected", "2025-12-03T14:30:00", false, ""},
+		{"utc required accepts utc", "2025-12-03T14:30:00Z", true, "2025-12-03T14:30:00Z"},
+		{"utc required accepts zero offset", "2025-12-03T14:30:00+00:00", true, "2025-12-03T14:30:00Z"},
+		{"utc required rejects offset", "2025-12-03T11:30:00-03:00", true, ""},
+		{"utc required rejects zone-less", "2025-12-03T14:30:00", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New(Options{RequireUTC: tt.requireUTC})
+
+			msg, err := v.ValidateMessage(createTimestampMessage(tt.timestamp))
+			if tt.expected == "" {
+				validationErr, ok := err.(ValidationError)
+				if !ok || validationErr.Field != "timestamp" || validationErr.Code != CodeInvalidFormat {
+					t.Errorf("Expected invalid_format timestamp error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected %v to be accepted, got: %v", tt.timestamp, err)
+			}
+			if msg.Timestamp != tt.expected {
+				t.Errorf("Expected normalized %s, got %s", tt.expected, msg.Timestamp)
+			}
+		})
+	}
+}
+
+func TestValidator_RequireUTCAcceptsEpochTimestamps(t *testing.T) {
+	v := New(Options{TimestampFormats: []string{FormatUnix}, RequireUTC: true})
+
+	msg, err := v.ValidateMessage(createTimestampMessage(1764772200))
+	if err != nil {
+		t.Fatalf("Expected epoch timestamp to be accepted, got: %v", err)
+	}
+	if msg.Timestamp != "2025-12-03T14:30:00Z" {
+		t.Errorf("Expected 2025-12-03T14:30:00Z, got %s", msg.Timestamp)
+	}
+}
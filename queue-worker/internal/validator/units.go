@@ -0,0 +1,79 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Wind speed units accepted in Options.WindSpeedUnits. Messages are converted
+// to UnitMetersPerSecond, the unit the API stores.
+const (
+	UnitMetersPerSecond   = "m/s"
+	UnitKilometersPerHour = "km/h"
+	UnitMilesPerHour      = "mph"
+)
+
+// metersPerSecond is the factor converting each unit to m/s
+var metersPerSecond = map[string]float64{
+	UnitMetersPerSecond:   1,
+	UnitKilometersPerHour: 1 / 3.6,
+	UnitMilesPerHour:      0.44704,
+}
+
+// Temperature units accepted in Options.TemperatureUnits. Messages are
+// converted to UnitCelsius.
+const (
+	UnitCelsius    = "C"
+	UnitFahrenheit = "F"
+)
+
+// Plausible temperature range in Celsius, a little beyond the recorded
+// extremes (-89.2 and 56.7), so anything outside is a unit or sensor error
+const (
+	MinPlausibleTemperature = -90.0
+	MaxPlausibleTemperature = 60.0
+)
+
+// CheckWindSpeedUnits returns an error naming the first source configured
+// with an unknown wind speed unit
+func CheckWindSpeedUnits(units map[string]string) error {
+	for source, unit := range units {
+		if _, ok := metersPerSecond[unit]; !ok {
+			return fmt.Errorf("unknown wind speed unit %q for source %q (expected %s, %s or %s)",
+				unit, source, UnitMetersPerSecond, UnitKilometersPerHour, UnitMilesPerHour)
+		}
+	}
+	return nil
+}
+
+// CheckTemperatureUnits returns an error naming the first source configured
+// with an unknown temperature unit
+func CheckTemperatureUnits(units map[string]string) error {
+	for source, unit := range units {
+		if unit != UnitCelsius && unit != UnitFahrenheit {
+			return fmt.Errorf("unknown temperature unit %q for source %q (expected %s or %s)",
+				unit, source, UnitCelsius, UnitFahrenheit)
+		}
+	}
+	return nil
+}
+
+// convertUnits rewrites a raw message's wind speed in m/s and temperature in
+// Celsius, using the units configured for its source. Without wind speed
+// units configured, wind speed is left as sent; otherwise it is labeled m/s,
+// the unit of every converted message.
+func (v *Validator) convertUnits(msg *WeatherMessage) {
+	source := strings.TrimSpace(msg.Source)
+
+	if v.temperatureUnits[source] == UnitFahrenheit {
+		msg.Weather.Temperature = (msg.Weather.Temperature - 32) * 5 / 9
+	}
+
+	if len(v.windSpeedUnits) == 0 {
+		return
+	}
+	if factor, ok := metersPerSecond[v.windSpeedUnits[source]]; ok {
+		msg.Weather.WindSpeed *= factor
+	}
+	msg.Weather.WindSpeedUnit = UnitMetersPerSecond
+}
@@ -0,0 +1,194 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// Unit tests for wind speed unit conversion
+
+func createWindSpeedMessage(source string, windSpeed float64) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	msg["source"] = source
+	msg["weather"].(map[string]interface{})["windSpeed"] = windSpeed
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_ConvertsWindSpeedToMetersPerSecond(t *testing.T) {
+	v := New(Options{WindSpeedUnits: map[string]string{
+		"open-meteo":  UnitKilometersPerHour,
+		"openweather": UnitMilesPerHour,
+	}})
+
+	tests := []struct {
+		name   string
+		source string
+		input  float64
+		want   float64
+	}{
+		{name: "km/h", source: "open-meteo", input: 36, want: 10},
+		{name: "mph", source: "openweather", input: 10, want: 4.4704},
+		{name: "unlisted source is m/s", source: "station-42", input: 12.3, want: 12.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := v.ValidateMessage(createWindSpeedMessage(tt.source, tt.input))
+			if err != nil {
+				t.Fatalf("Expected valid message, got: %v", err)
+			}
+			if math.Abs(msg.Weather.WindSpeed-tt.want) > 1e-9 {
+				t.Errorf("Expected %v m/s, got %v", tt.want, msg.Weather.WindSpeed)
+			}
+			if msg.Weather.WindSpeedUnit != UnitMetersPerSecond {
+				t.Errorf("Expected unit %s, got %q", UnitMetersPerSecond, msg.Weather.WindSpeedUnit)
+			}
+		})
+	}
+}
+
+func TestValidator_MaxWindSpeedChecksConvertedValue(t *testing.T) {
+	v := New(Options{
+		WindSpeedUnits: map[string]string{"open-meteo": UnitKilometersPerHour, "openweather": UnitMilesPerHour},
+		MaxWindSpeed:   50,
+	})
+
+	// 150 km/h is about 41.7 m/s: within bounds once converted
+	if _, err := v.ValidateMessage(createWindSpeedMessage("open-meteo", 150)); err != nil {
+		t.Errorf("Expected 150 km/h to be within 50 m/s, got: %v", err)
+	}
+
+	// 120 mph is about 53.6 m/s
+	_, err := v.ValidateMessage(createWindSpeedMessage("openweather", 120))
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "weather.windSpeed" || validationErr.Code != CodeOutOfRange {
+		t.Errorf("Expected weather.windSpeed out_of_range, got %v", err)
+	}
+}
+
+func TestValidator_WindSpeedUnchangedWithoutUnits(t *testing.T) {
+	msg, err := New(Options{}).ValidateMessage(createWindSpeedMessage("open-meteo", 36))
+	if err != nil {
+		t.Fatalf("Expected valid message, got: %v", err)
+	}
+	if msg.Weather.WindSpeed != 36 || msg.Weather.WindSpeedUnit != "" {
+		t.Errorf("Expected wind speed left as sent, got %v %q", msg.Weather.WindSpeed, msg.Weather.WindSpeedUnit)
+	}
+}
+
+func TestCheckWindSpeedUnits(t *testing.T) {
+	if err := CheckWindSpeedUnits(map[string]string{"open-meteo": "km/h", "openweather": "mph", "station": "m/s"}); err != nil {
+		t.Errorf("Expected known units to pass, got %v", err)
+	}
+	if err := CheckWindSpeedUnits(map[string]string{"open-meteo": "knots"}); err == nil {
+		t.Error("Expected unknown unit to fail")
+	}
+}
+
+func createTemperatureMessage(source string, temperature float64) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	msg["source"] = source
+	msg["weather"].(map[string]interface{})["temperature"] = temperature
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_ConvertsFahrenheitToCelsius(t *testing.T) {
+	v := New(Options{TemperatureUnits: map[string]string{"us-feed": UnitFahrenheit, "open-meteo": UnitCelsius}})
+
+	tests := []struct {
+		name   string
+		source string
+		input  float64
+		want   float64
+	}{
+		{name: "body temperature", source: "us-feed", input: 98.6, want: 37},
+		{name: "freezing", source: "us-feed", input: 32, want: 0},
+		{name: "negative", source: "us-feed", input: -40, want: -40},
+		{name: "below zero fahrenheit", source: "us-feed", input: -4, want: -20},
+		{name: "celsius source", source: "open-meteo", input: 38.6, want: 38.6},
+		{name: "unlisted source is celsius", source: "station-42", input: 21.5, want: 21.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := v.ValidateMessage(createTemperatureMessage(tt.source, tt.input))
+			if err != nil {
+				t.Fatalf("Expected valid message, got: %v", err)
+			}
+			if math.Abs(msg.Weather.Temperature-tt.want) > 1e-9 {
+				t.Errorf("Expected %v °C, got %v", tt.want, msg.Weather.Temperature)
+			}
+		})
+	}
+}
+
+func TestValidator_PlausibleTemperatureAfterConversion(t *testing.T) {
+	v := New(Options{TemperatureUnits: map[string]string{"us-feed": UnitFahrenheit}, RejectImplausibleTemperature: true})
+
+	tests := []struct {
+		name   string
+		source string
+		input  float64
+		valid  bool
+	}{
+		// 140 °F is exactly 60 °C, the top of the range
+		{name: "upper bound in fahrenheit", source: "us-feed", input: 140, valid: true},
+		{name: "above upper bound in fahrenheit", source: "us-feed", input: 140.5, valid: false},
+		// -130 °F is exactly -90 °C, the bottom of the range
+		{name: "lower bound in fahrenheit", source: "us-feed", input: -130, valid: true},
+		{name: "below lower bound in fahrenheit", source: "us-feed", input: -131, valid: false},
+		{name: "fahrenheit value read as celsius", source: "open-meteo", input: 98.6, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.ValidateMessage(createTemperatureMessage(tt.source, tt.input))
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid, got: %v", err)
+			}
+			var validationErr ValidationError
+			if !tt.valid && (!errors.As(err, &validationErr) || validationErr.Field != "weather.temperature" || validationErr.Code != CodeOutOfRange) {
+				t.Errorf("Expected weather.temperature out_of_range, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidator_ImplausibleTemperatureAcceptedByDefault(t *testing.T) {
+	v := New(Options{})
+
+	msg, err := v.ValidateMessage(createTemperatureMessage("open-meteo", 98.6))
+	if err != nil {
+		t.Fatalf("Expected valid message without RejectImplausibleTemperature, got: %v", err)
+	}
+	if msg.Weather.Temperature != 98.6 {
+		t.Errorf("Expected temperature kept as 98.6, got %v", msg.Weather.Temperature)
+	}
+}
+
+func TestCheckTemperatureUnits(t *testing.T) {
+	if err := CheckTemperatureUnits(map[string]string{"us-feed": "F", "open-meteo": "C"}); err != nil {
+		t.Errorf("Expected known units to pass, got %v", err)
+	}
+	if err := CheckTemperatureUnits(map[string]string{"us-feed": "K"}); err == nil {
+		t.Error("Expected unknown unit to fail")
+	}
+}
+
+func TestValidator_RejectsInfiniteTemperatureAfterConversion(t *testing.T) {
+	v := New(Options{TemperatureUnits: map[string]string{"us-feed": UnitFahrenheit}})
+
+	// Finite in the body, but (F - 32) * 5 overflows to +Inf
+	_, err := v.ValidateMessage(createTemperatureMessage("us-feed", math.MaxFloat64))
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "weather.temperature" || validationErr.Code != CodeNotFinite {
+		t.Errorf("Expected not_finite error on weather.temperature, got %v", err)
+	}
+}
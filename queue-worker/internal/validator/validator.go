@@ -3,7 +3,12 @@ package validator
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
+
+	"queue-worker/internal/schema"
 )
 
 // Location represents the location data in a weather message
@@ -12,6 +17,8 @@ type Location struct {
 	State     string  `json:"state,omitempty"`
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
+	// Region is derived from the coordinates by the worker, not sent by producers
+	Region string `json:"region,omitempty"`
 }
 
 // Weather represents the weather data in a message
@@ -20,7 +27,14 @@ type Weather struct {
 	Humidity        float64 `json:"humidity"`
 	WindSpeed       float64 `json:"windSpeed"`
 	Condition       string  `json:"condition"`
-	RainProbability float64 `json:"rainProbability"`
+	// RainProbability is nil when the source doesn't report it, which isn't
+	// the same as a 0% chance (see Options.MissingRainProbability)
+	RainProbability *float64 `json:"rainProbability,omitempty"`
+	// WindDirection is in degrees (0-360); nil when the source doesn't report it
+	WindDirection *float64 `json:"windDirection,omitempty"`
+	// WindSpeedUnit is set by the worker to UnitMetersPerSecond once it has
+	// converted WindSpeed (see Options.WindSpeedUnits)
+	WindSpeedUnit string `json:"windSpeedUnit,omitempty"`
 }
 
 // WeatherMessage represents the complete weather message structure
@@ -29,11 +43,41 @@ type WeatherMessage struct {
 	Location  Location `json:"location"`
 	Weather   Weather  `json:"weather"`
 	Source    string   `json:"source"`
+	// SchemaVersion is the producer's message format version; zero when the
+	// producer predates versioning (see Version)
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// Type is TypeAlert for severe-weather alerts, which also carry the
+	// Alert fields; empty or TypeReading for routine readings
+	Type string `json:"type,omitempty"`
+	*Alert
 }
 
+// DefaultSchemaVersion is the version of messages that don't declare one
+const DefaultSchemaVersion = 1
+
+// Version returns the message's schema version, DefaultSchemaVersion if absent
+func (msg *WeatherMessage) Version() int {
+	if msg.SchemaVersion == 0 {
+		return DefaultSchemaVersion
+	}
+	return msg.SchemaVersion
+}
+
+// Validation error codes identify the kind of rule a field failed
+const (
+	CodeRequired      = "required"
+	CodeInvalidFormat = "invalid_format"
+	CodeOutOfRange    = "out_of_range"
+	CodeSuspicious    = "suspicious"
+	CodeInconsistent  = "inconsistent"
+	CodeUnsupported   = "unsupported_version"
+	CodeNotFinite     = "not_finite"
+)
+
 // ValidationError represents a validation error with details
 type ValidationError struct {
 	Field   string
+	Code    string
 	Message string
 }
 
@@ -41,66 +85,574 @@ func (e ValidationError) Error() string {
 	return e.Field + ": " + e.Message
 }
 
+// ValidationErrors is every rule a message failed, in field order
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Policies for a message without rainProbability
+const (
+	// MissingDefault fills in Options.DefaultRainProbability
+	MissingDefault = "default"
+	// MissingRequire rejects the message
+	MissingRequire = "require"
+	// MissingOmit leaves it out of the outgoing payload
+	MissingOmit = "omit"
+)
+
+// CheckMissingPolicy returns an error if policy isn't a known policy for a
+// missing rainProbability
+func CheckMissingPolicy(policy string) error {
+	switch policy {
+	case "", MissingDefault, MissingRequire, MissingOmit:
+		return nil
+	}
+	return fmt.Errorf("unknown missing-value policy %q (expected %s, %s or %s)", policy, MissingDefault, MissingRequire, MissingOmit)
+}
+
+// DefaultRequiredFields is the default required-fields profile
+var DefaultRequiredFields = []string{"timestamp", "location.city", "weather.condition", "source"}
+
+// Options configures the rules applied by a Validator
+type Options struct {
+	// RequiredFields lists the field paths (e.g. "weather.rainProbability")
+	// that must be present. Nil uses DefaultRequiredFields.
+	RequiredFields []string
+
+	// TimestampFormats lists the accepted timestamp formats, tried in order.
+	// Nil uses DefaultTimestampFormats.
+	TimestampFormats []string
+	// RequireUTC rejects RFC3339 timestamps written with a non-zero offset,
+	// for deployments that demand producers send UTC. Timestamps are
+	// normalized to UTC either way.
+	RequireUTC bool
+
+	// RequireSourceMatchesPublisher makes CheckPublisher reject messages
+	// whose source differs from the publisher id on their delivery
+	RequireSourceMatchesPublisher bool
+
+	// RejectNullIsland flags coordinates of exactly 0,0, which usually mean
+	// the producer defaulted missing values rather than a real location
+	RejectNullIsland bool
+
+	// RejectImplausibleTemperature flags temperatures outside
+	// MinPlausibleTemperature and MaxPlausibleTemperature, after unit
+	// conversion, as a unit or sensor error
+	RejectImplausibleTemperature bool
+
+	// CrossFieldChecks flags fields that contradict each other, such as a
+	// precipitation condition whose rainProbability isn't above
+	// MinRainProbability
+	CrossFieldChecks   bool
+	MinRainProbability float64
+
+	// MissingRainProbability is the policy for messages without
+	// rainProbability: MissingDefault (the default) fills in
+	// DefaultRainProbability, MissingRequire rejects them and MissingOmit
+	// sends them without it
+	MissingRainProbability string
+	DefaultRainProbability float64
+
+	// MinSchemaVersion rejects messages whose schema version is lower, so
+	// old-format messages can be routed for migration. Zero accepts any.
+	MinSchemaVersion int
+
+	// WindSpeedUnits maps a source to the unit it reports wind speed in
+	// (UnitMetersPerSecond, UnitKilometersPerHour or UnitMilesPerHour).
+	// Raw messages are converted to m/s before validation; sources not
+	// listed are taken to report m/s. Nil leaves wind speed as sent.
+	WindSpeedUnits map[string]string
+	// MaxWindSpeed rejects wind speeds above this many m/s, checked after
+	// conversion. Zero disables the check.
+	MaxWindSpeed float64
+
+	// TemperatureUnits maps a source to the unit it reports temperature in
+	// (UnitCelsius or UnitFahrenheit). Raw messages are converted to Celsius
+	// before validation; sources not listed report Celsius.
+	TemperatureUnits map[string]string
+
+	// RoundCoordinates rounds latitude and longitude to CoordinateDecimals
+	// decimal places on messages that pass validation. It only normalizes:
+	// the unrounded values are what validation checks.
+	RoundCoordinates   bool
+	CoordinateDecimals int
+
+	// SchemaProvider supplies the JSON Schema registered for each source and
+	// schema version. Raw messages are checked against it as SchemaMode says
+	// (SchemaModeAdditional by default). When a schema can't be fetched,
+	// SchemaFailOpen accepts the message; otherwise it is rejected with
+	// CodeSchemaUnavailable. Nil uses only the built-in rules.
+	SchemaProvider schema.Provider
+	SchemaMode     string
+	SchemaFailOpen bool
+}
+
+// precipitationConditions are the conditions that imply a chance of rain
+var precipitationConditions = map[string]bool{"rainy": true, "stormy": true, "snowy": true}
+
+// Validator validates weather messages against a configured set of rules
+type Validator struct {
+	required         map[string]bool
+	timestampFormats []string
+	requireUTC       bool
+	rejectNullIsland bool
+	checkPlausible   bool // see Options.RejectImplausibleTemperature
+
+	sourceMatchesPublisher bool // see CheckPublisher
+
+	crossField       bool
+	minRain          float64
+	minSchema        int
+	defaultRain      *float64 // filled in for a missing rainProbability, if set
+	windSpeedUnits   map[string]string
+	maxWindSpeed     float64
+	temperatureUnits map[string]string
+	coordinateScale  float64 // 10^decimals when rounding coordinates, else 0
+	schemas          schema.Provider
+	schemaMode       string
+	schemaFailOpen   bool
+}
+
+// New creates a Validator for the given options
+func New(opts Options) *Validator {
+	fields := opts.RequiredFields
+	if fields == nil {
+		fields = DefaultRequiredFields
+	}
+
+	required := make(map[string]bool, len(fields)+1)
+	for _, field := range fields {
+		required[field] = true
+	}
+
+	var defaultRain *float64
+	switch opts.MissingRainProbability {
+	case MissingRequire:
+		required["weather.rainProbability"] = true
+	case MissingOmit:
+	default:
+		rain := opts.DefaultRainProbability
+		defaultRain = &rain
+	}
+
+	formats := opts.TimestampFormats
+	if formats == nil {
+		formats = DefaultTimestampFormats
+	}
+
+	var coordinateScale float64
+	if opts.RoundCoordinates {
+		coordinateScale = math.Pow10(opts.CoordinateDecimals)
+	}
+	return &Validator{
+		required:         required,
+		timestampFormats: formats,
+		requireUTC:       opts.RequireUTC,
+		rejectNullIsland: opts.RejectNullIsland,
+		checkPlausible:   opts.RejectImplausibleTemperature,
+		crossField:       opts.CrossFieldChecks,
+		minRain:          opts.MinRainProbability,
+		minSchema:        opts.MinSchemaVersion,
+		defaultRain:      defaultRain,
+		windSpeedUnits:   opts.WindSpeedUnits,
+		maxWindSpeed:     opts.MaxWindSpeed,
+		temperatureUnits: opts.TemperatureUnits,
+		coordinateScale:  coordinateScale,
+		schemas:          opts.SchemaProvider,
+		schemaMode:       opts.SchemaMode,
+		schemaFailOpen:   opts.SchemaFailOpen,
+
+		sourceMatchesPublisher: opts.RequireSourceMatchesPublisher,
+	}
+}
+
+// defaultValidator applies the default rules for the package-level functions
+var defaultValidator = New(Options{})
+
+// CheckFieldPaths returns an error naming the first path that isn't a known
+// message field, so misconfigured profiles fail at startup
+func CheckFieldPaths(paths []string) error {
+	for _, path := range paths {
+		if _, ok := fieldRuleByPath[path]; !ok {
+			return fmt.Errorf("unknown field path %q", path)
+		}
+	}
+	return nil
+}
+
 // ValidateMessage validates a raw JSON message and returns the parsed WeatherMessage
 func ValidateMessage(data []byte) (*WeatherMessage, error) {
-	if len(data) == 0 {
-		return nil, errors.New("empty message")
+	return defaultValidator.ValidateMessage(data)
+}
+
+// ValidateMessage validates a raw JSON message and returns the parsed WeatherMessage
+func (v *Validator) ValidateMessage(data []byte) (*WeatherMessage, error) {
+	msg, present, err := parseMessage(data)
+	if err != nil {
+		return nil, err
 	}
+	v.convertUnits(msg)
 
-	var msg WeatherMessage
-	if err := json.Unmarshal(data, &msg); err != nil {
-		return nil, errors.New("invalid JSON format: " + err.Error())
+	if errs := v.rawMessageErrors(data, msg, present, true); len(errs) > 0 {
+		return nil, errs[0]
 	}
+	v.roundCoordinates(msg)
+
+	return msg, nil
+}
 
-	if err := validateWeatherMessage(&msg); err != nil {
+// ValidateMessageAll validates a raw JSON message like ValidateMessage, but
+// reports every failed rule as ValidationErrors instead of stopping at the
+// first. Bodies that aren't JSON objects still fail with a single error.
+func ValidateMessageAll(data []byte) (*WeatherMessage, error) {
+	return defaultValidator.ValidateMessageAll(data)
+}
+
+// ValidateMessageAll validates a raw JSON message, collecting every error
+func (v *Validator) ValidateMessageAll(data []byte) (*WeatherMessage, error) {
+	msg, present, err := parseMessage(data)
+	if err != nil {
 		return nil, err
 	}
+	v.convertUnits(msg)
+
+	if errs := v.rawMessageErrors(data, msg, present, false); len(errs) > 0 {
+		return nil, errs
+	}
+	v.roundCoordinates(msg)
 
-	return &msg, nil
+	return msg, nil
 }
 
+// parseMessage decodes a raw JSON message along with the dotted paths of the
+// fields it contains
+func parseMessage(data []byte) (*WeatherMessage, map[string]bool, error) {
+	if len(data) == 0 {
+		return nil, nil, errors.New("empty message")
+	}
 
-// validateWeatherMessage validates all fields of a WeatherMessage
-func validateWeatherMessage(msg *WeatherMessage) error {
-	// Validate timestamp
-	if msg.Timestamp == "" {
-		return ValidationError{Field: "timestamp", Message: "required field is missing"}
+	var msg WeatherMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, nil, errors.New("invalid JSON format: " + err.Error())
 	}
-	if _, err := time.Parse(time.RFC3339, msg.Timestamp); err != nil {
-		return ValidationError{Field: "timestamp", Message: "invalid format, expected RFC3339"}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, errors.New("invalid JSON format: " + err.Error())
 	}
+	present := make(map[string]bool)
+	collectPresentFields(raw, "", present)
 
-	// Validate location
-	if msg.Location.City == "" {
-		return ValidationError{Field: "location.city", Message: "required field is missing"}
+	return &msg, present, nil
+}
+
+// Validate checks the message against the same rules as ValidateMessage,
+// for callers that already hold a parsed WeatherMessage
+func (msg *WeatherMessage) Validate() error {
+	return defaultValidator.Validate(msg)
+}
+
+// Validate checks an already parsed message. Without the raw JSON, numeric
+// fields can't be told apart from zero, so only empty strings and nil
+// optional values count as missing, and the registered schema isn't checked.
+// The rules run on a copy, so the normalization ValidateMessage applies
+// leaves msg unchanged.
+func (v *Validator) Validate(msg *WeatherMessage) error {
+	return v.validateWeatherMessage(msg.clone(), nil)
+}
+
+// clone returns a deep copy of msg
+func (msg *WeatherMessage) clone() *WeatherMessage {
+	copied := *msg
+	if msg.Alert != nil {
+		alert := *msg.Alert
+		copied.Alert = &alert
 	}
-	if msg.Location.Latitude < -90 || msg.Location.Latitude > 90 {
-		return ValidationError{Field: "location.latitude", Message: "must be between -90 and 90"}
+	copied.Weather.RainProbability = cloneFloat(msg.Weather.RainProbability)
+	copied.Weather.WindDirection = cloneFloat(msg.Weather.WindDirection)
+	return &copied
+}
+
+// cloneFloat returns a copy of the value f points to, or nil
+func cloneFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
 	}
-	if msg.Location.Longitude < -180 || msg.Location.Longitude > 180 {
-		return ValidationError{Field: "location.longitude", Message: "must be between -180 and 180"}
+	copied := *f
+	return &copied
+}
+
+// fieldRule describes how one field is checked
+type fieldRule struct {
+	path string
+	// missing reports whether the parsed value is absent; nil when absence
+	// can only be detected from the raw JSON
+	missing func(msg *WeatherMessage) bool
+	// check validates a present value; nil when any value is accepted
+	check func(v *Validator, msg *WeatherMessage) error
+	// defaulted fields are never missing: an absent value takes a default
+	// that is still checked
+	defaulted bool
+}
+
+// fieldRules lists every field in the order its errors are reported
+var fieldRules = []fieldRule{
+	{
+		path:    "type",
+		missing: func(msg *WeatherMessage) bool { return msg.Type == "" },
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if msg.Type != TypeReading && msg.Type != TypeAlert {
+				return ValidationError{Field: "type", Code: CodeInvalidFormat, Message: "must be " + TypeReading + " or " + TypeAlert}
+			}
+			return nil
+		},
+	},
+	{
+		path:      "schemaVersion",
+		defaulted: true,
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if msg.Version() < v.minSchema {
+				return ValidationError{Field: "schemaVersion", Code: CodeUnsupported, Message: fmt.Sprintf("schema version %d is below the minimum %d", msg.Version(), v.minSchema)}
+			}
+			return nil
+		},
+	},
+	{
+		path:    "timestamp",
+		missing: func(msg *WeatherMessage) bool { return msg.Timestamp == "" },
+		check: func(v *Validator, msg *WeatherMessage) error {
+			t, format, ok := v.parseTimestampFormat(msg.Timestamp)
+			if !ok {
+				return ValidationError{Field: "timestamp", Code: CodeInvalidFormat, Message: "invalid format, expected one of: " + strings.Join(v.timestampFormats, ", ")}
+			}
+			if v.requireUTC && nonUTCOffset(t, format) {
+				return ValidationError{Field: "timestamp", Code: CodeInvalidFormat, Message: "must be in UTC (Z or +00:00 offset)"}
+			}
+			// Normalize so the API sees one representation whatever the producer sent
+			msg.Timestamp = t.UTC().Format(time.RFC3339Nano)
+			return nil
+		},
+	},
+	{
+		path:    "location.city",
+		missing: func(msg *WeatherMessage) bool { return msg.Location.City == "" },
+	},
+	{
+		path:    "location.state",
+		missing: func(msg *WeatherMessage) bool { return msg.Location.State == "" },
+	},
+	{
+		path: "location.latitude",
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if err := checkFinite("location.latitude", msg.Location.Latitude); err != nil {
+				return err
+			}
+			if msg.Location.Latitude < -90 || msg.Location.Latitude > 90 {
+				return ValidationError{Field: "location.latitude", Code: CodeOutOfRange, Message: "must be between -90 and 90"}
+			}
+			return nil
+		},
+	},
+	{
+		path: "location.longitude",
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if err := checkFinite("location.longitude", msg.Location.Longitude); err != nil {
+				return err
+			}
+			if msg.Location.Longitude < -180 || msg.Location.Longitude > 180 {
+				return ValidationError{Field: "location.longitude", Code: CodeOutOfRange, Message: "must be between -180 and 180"}
+			}
+			return nil
+		},
+	},
+	{
+		path: "location",
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if v.rejectNullIsland && msg.Location.Latitude == 0 && msg.Location.Longitude == 0 {
+				return ValidationError{Field: "location", Code: CodeSuspicious, Message: "coordinates are null island (0,0)"}
+			}
+			return nil
+		},
+	},
+	{
+		path: "weather.temperature",
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if err := checkFinite("weather.temperature", msg.Weather.Temperature); err != nil {
+				return err
+			}
+			if !v.checkPlausible {
+				return nil
+			}
+			if msg.Weather.Temperature < MinPlausibleTemperature || msg.Weather.Temperature > MaxPlausibleTemperature {
+				return ValidationError{Field: "weather.temperature", Code: CodeOutOfRange, Message: fmt.Sprintf("must be between %g and %g °C", MinPlausibleTemperature, MaxPlausibleTemperature)}
+			}
+			return nil
+		},
+	},
+	{
+		path: "weather.humidity",
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if err := checkFinite("weather.humidity", msg.Weather.Humidity); err != nil {
+				return err
+			}
+			if msg.Weather.Humidity < 0 || msg.Weather.Humidity > 100 {
+				return ValidationError{Field: "weather.humidity", Code: CodeOutOfRange, Message: "must be between 0 and 100"}
+			}
+			return nil
+		},
+	},
+	{
+		path: "weather.windSpeed",
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if err := checkFinite("weather.windSpeed", msg.Weather.WindSpeed); err != nil {
+				return err
+			}
+			if msg.Weather.WindSpeed < 0 {
+				return ValidationError{Field: "weather.windSpeed", Code: CodeOutOfRange, Message: "must be non-negative"}
+			}
+			if v.maxWindSpeed > 0 && msg.Weather.WindSpeed > v.maxWindSpeed {
+				return ValidationError{Field: "weather.windSpeed", Code: CodeOutOfRange, Message: fmt.Sprintf("must be at most %g m/s", v.maxWindSpeed)}
+			}
+			return nil
+		},
+	},
+	{
+		path:    "weather.windDirection",
+		missing: func(msg *WeatherMessage) bool { return msg.Weather.WindDirection == nil },
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if err := checkFinite("weather.windDirection", *msg.Weather.WindDirection); err != nil {
+				return err
+			}
+			if *msg.Weather.WindDirection < 0 || *msg.Weather.WindDirection > 360 {
+				return ValidationError{Field: "weather.windDirection", Code: CodeOutOfRange, Message: "must be between 0 and 360"}
+			}
+			return nil
+		},
+	},
+	{
+		path:    "weather.condition",
+		missing: func(msg *WeatherMessage) bool { return msg.Weather.Condition == "" },
+	},
+	{
+		path:    "weather.rainProbability",
+		missing: func(msg *WeatherMessage) bool { return msg.Weather.RainProbability == nil },
+		check: func(v *Validator, msg *WeatherMessage) error {
+			if err := checkFinite("weather.rainProbability", *msg.Weather.RainProbability); err != nil {
+				return err
+			}
+			if *msg.Weather.RainProbability < 0 || *msg.Weather.RainProbability > 100 {
+				return ValidationError{Field: "weather.rainProbability", Code: CodeOutOfRange, Message: "must be between 0 and 100"}
+			}
+			return nil
+		},
+	},
+	{
+		path: "weather",
+		check: func(v *Validator, msg *WeatherMessage) error {
+			condition := strings.ToLower(msg.Weather.Condition)
+			rain := msg.Weather.RainProbability
+			if v.crossField && precipitationConditions[condition] && rain != nil && *rain <= v.minRain {
+				return ValidationError{Field: "weather", Code: CodeInconsistent, Message: "rain probability inconsistent with condition"}
+			}
+			return nil
+		},
+	},
+	{
+		path:    "source",
+		missing: func(msg *WeatherMessage) bool { return msg.Source == "" },
+	},
+}
+
+// checkFinite rejects NaN and ±Inf, which JSON can't carry but a lenient
+// producer encoder or a unit conversion can produce. NaN would otherwise pass
+// every range check, since all comparisons with it are false.
+func checkFinite(field string, value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return ValidationError{Field: field, Code: CodeNotFinite, Message: "must be a finite number"}
 	}
+	return nil
+}
 
-	// Validate weather data
-	if msg.Weather.Humidity < 0 || msg.Weather.Humidity > 100 {
-		return ValidationError{Field: "weather.humidity", Message: "must be between 0 and 100"}
+var fieldRuleByPath = func() map[string]fieldRule {
+	rules := make(map[string]fieldRule, len(fieldRules))
+	for _, rule := range fieldRules {
+		rules[rule.path] = rule
 	}
-	if msg.Weather.WindSpeed < 0 {
-		return ValidationError{Field: "weather.windSpeed", Message: "must be non-negative"}
+	return rules
+}()
+
+// validateWeatherMessage validates all fields of a WeatherMessage. present
+// holds the field paths found in the raw JSON, or nil if it isn't available.
+// A missing field is an error only if the profile requires it; otherwise its
+// value checks are skipped.
+func (v *Validator) validateWeatherMessage(msg *WeatherMessage, present map[string]bool) error {
+	if errs := v.fieldErrors(msg, present, true); len(errs) > 0 {
+		return errs[0]
 	}
-	if msg.Weather.Condition == "" {
-		return ValidationError{Field: "weather.condition", Message: "required field is missing"}
+	return nil
+}
+
+// fieldErrors applies every field rule in order, stopping after the first
+// failure when firstOnly is set
+func (v *Validator) fieldErrors(msg *WeatherMessage, present map[string]bool, firstOnly bool) ValidationErrors {
+	v.applyDefaults(msg)
+
+	var errs ValidationErrors
+	for _, rule := range fieldRules {
+		missing := !rule.defaulted && ((rule.missing != nil && rule.missing(msg)) || (present != nil && !present[rule.path]))
+		if missing {
+			if v.required[rule.path] {
+				errs = append(errs, ValidationError{Field: rule.path, Code: CodeRequired, Message: "required field is missing"})
+			}
+		} else if rule.check != nil {
+			var validationErr ValidationError
+			if err := rule.check(v, msg); errors.As(err, &validationErr) {
+				errs = append(errs, validationErr)
+			}
+		}
+
+		if firstOnly && len(errs) > 0 {
+			return errs
+		}
 	}
-	if msg.Weather.RainProbability < 0 || msg.Weather.RainProbability > 100 {
-		return ValidationError{Field: "weather.rainProbability", Message: "must be between 0 and 100"}
+
+	if msg.IsAlert() {
+		alertErrs := alertErrors(msg)
+		if firstOnly && len(alertErrs) > 1 {
+			alertErrs = alertErrs[:1]
+		}
+		errs = append(errs, alertErrs...)
 	}
+	return errs
+}
 
-	// Validate source
-	if msg.Source == "" {
-		return ValidationError{Field: "source", Message: "required field is missing"}
+// applyDefaults fills in missing optional values the policy has defaults for
+func (v *Validator) applyDefaults(msg *WeatherMessage) {
+	if msg.Weather.RainProbability == nil && v.defaultRain != nil {
+		rain := *v.defaultRain
+		msg.Weather.RainProbability = &rain
 	}
+}
 
-	return nil
+// collectPresentFields records the dotted path of every non-null value in a
+// decoded JSON object
+func collectPresentFields(obj map[string]interface{}, prefix string, present map[string]bool) {
+	for key, value := range obj {
+		if value == nil {
+			continue
+		}
+		path := prefix + key
+		present[path] = true
+		if nested, ok := value.(map[string]interface{}); ok {
+			collectPresentFields(nested, path+".", present)
+		}
+	}
 }
 
 // IsValid checks if a message is valid without returning the parsed message
@@ -1,7 +1,12 @@
 package validator
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -90,6 +95,9 @@ func TestValidateMessage_MissingTimestamp(t *testing.T) {
 	if validationErr.Field != "timestamp" {
 		t.Errorf("Expected field 'timestamp', got '%s'", validationErr.Field)
 	}
+	if validationErr.Code != CodeRequired {
+		t.Errorf("Expected code '%s', got '%s'", CodeRequired, validationErr.Code)
+	}
 }
 
 func TestValidateMessage_InvalidTimestampFormat(t *testing.T) {
@@ -408,3 +416,528 @@ func TestValidationError_Error(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expected, err.Error())
 	}
 }
+
+func TestWeatherMessage_Validate(t *testing.T) {
+	rainProbability := 30.0
+	msg := &WeatherMessage{
+		Timestamp: "2025-12-03T14:30:00Z",
+		Location:  Location{City: "São Paulo", Latitude: -23.5505, Longitude: -46.6333},
+		Weather:   Weather{Temperature: 28.5, Humidity: 65, WindSpeed: 12.3, Condition: "partly_cloudy", RainProbability: &rainProbability},
+		Source:    "open-meteo",
+	}
+
+	if err := msg.Validate(); err != nil {
+		t.Errorf("Expected valid message to pass validation, got error: %v", err)
+	}
+
+	msg.Weather.Humidity = 150
+	err := msg.Validate()
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Expected ValidationError, got %T", err)
+	}
+	if validationErr.Field != "weather.humidity" {
+		t.Errorf("Expected field 'weather.humidity', got '%s'", validationErr.Field)
+	}
+}
+
+func TestWeatherMessage_ValidateLeavesMessageUnchanged(t *testing.T) {
+	msg := &WeatherMessage{
+		Type:      TypeAlert,
+		Timestamp: "2025-12-03T11:30:00-03:00",
+		Location:  Location{City: "São Paulo", Latitude: -23.5505, Longitude: -46.6333},
+		Weather:   Weather{Temperature: 28.5, Humidity: 65, WindSpeed: 12.3, Condition: "stormy"},
+		Source:    "open-meteo",
+		Alert:     &Alert{Severity: "severe", Headline: "Storm warning", ExpiresAt: "2025-12-03T18:00:00-03:00"},
+	}
+	before := *msg
+	alert := *msg.Alert
+
+	if err := msg.Validate(); err != nil {
+		t.Fatalf("Expected valid message to pass validation, got error: %v", err)
+	}
+	if !reflect.DeepEqual(*msg, before) || *msg.Alert != alert {
+		t.Errorf("Expected Validate to leave the message unchanged, got %+v (alert %+v)", msg, msg.Alert)
+	}
+}
+
+func createWindDirectionMessage(windDirection interface{}) []byte {
+	weather := map[string]interface{}{
+		"temperature":     28.5,
+		"humidity":        65.0,
+		"windSpeed":       12.3,
+		"condition":       "partly_cloudy",
+		"rainProbability": 30.0,
+	}
+	if windDirection != nil {
+		weather["windDirection"] = windDirection
+	}
+	msg := map[string]interface{}{
+		"timestamp": "2025-12-03T14:30:00Z",
+		"location": map[string]interface{}{
+			"city":      "São Paulo",
+			"latitude":  -23.5505,
+			"longitude": -46.6333,
+		},
+		"weather": weather,
+		"source":  "open-meteo",
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidateMessage_WindDirectionPresent(t *testing.T) {
+	for _, direction := range []float64{0, 180, 360} {
+		result, err := ValidateMessage(createWindDirectionMessage(direction))
+		if err != nil {
+			t.Errorf("Expected wind direction %v to be valid, got error: %v", direction, err)
+			continue
+		}
+		if result.Weather.WindDirection == nil || *result.Weather.WindDirection != direction {
+			t.Errorf("Expected wind direction %v, got %v", direction, result.Weather.WindDirection)
+		}
+	}
+}
+
+func TestValidateMessage_WindDirectionAbsent(t *testing.T) {
+	result, err := ValidateMessage(createWindDirectionMessage(nil))
+	if err != nil {
+		t.Fatalf("Expected message without wind direction to be valid, got error: %v", err)
+	}
+	if result.Weather.WindDirection != nil {
+		t.Errorf("Expected nil wind direction, got %v", *result.Weather.WindDirection)
+	}
+
+	data, _ := json.Marshal(result)
+	if strings.Contains(string(data), "windDirection") {
+		t.Errorf("Expected windDirection to be omitted from payload, got %s", data)
+	}
+}
+
+func TestValidateMessage_WindDirectionOutOfRange(t *testing.T) {
+	for _, direction := range []float64{-1, 360.5} {
+		_, err := ValidateMessage(createWindDirectionMessage(direction))
+		validationErr, ok := err.(ValidationError)
+		if !ok {
+			t.Errorf("Expected ValidationError for wind direction %v, got %T", direction, err)
+			continue
+		}
+		if validationErr.Field != "weather.windDirection" {
+			t.Errorf("Expected field 'weather.windDirection', got '%s'", validationErr.Field)
+		}
+	}
+}
+
+func TestValidator_RelaxedProfile(t *testing.T) {
+	v := New(Options{RequiredFields: []string{"timestamp", "location.city", "source"}})
+
+	msg := map[string]interface{}{
+		"timestamp": "2025-12-03T14:30:00Z",
+		"location":  map[string]interface{}{"city": "São Paulo"},
+		"weather":   map[string]interface{}{"temperature": 28.5},
+		"source":    "open-meteo",
+	}
+	data, _ := json.Marshal(msg)
+
+	if _, err := v.ValidateMessage(data); err != nil {
+		t.Errorf("Expected relaxed profile to accept message without condition, got: %v", err)
+	}
+	if _, err := ValidateMessage(data); err == nil {
+		t.Error("Expected default profile to require condition")
+	}
+}
+
+func TestValidator_StrictProfile(t *testing.T) {
+	v := New(Options{RequiredFields: append(append([]string{}, DefaultRequiredFields...), "weather.rainProbability", "location.state")})
+
+	data := createWindDirectionMessage(nil)
+	var msg map[string]interface{}
+	json.Unmarshal(data, &msg)
+	msg["location"].(map[string]interface{})["state"] = "SP"
+	delete(msg["weather"].(map[string]interface{}), "rainProbability")
+	data, _ = json.Marshal(msg)
+
+	_, err := v.ValidateMessage(data)
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Expected ValidationError, got %T (%v)", err, err)
+	}
+	if validationErr.Field != "weather.rainProbability" || validationErr.Code != CodeRequired {
+		t.Errorf("Expected required weather.rainProbability, got %+v", validationErr)
+	}
+
+	if _, err := ValidateMessage(data); err != nil {
+		t.Errorf("Expected default profile to accept missing rainProbability, got: %v", err)
+	}
+}
+
+func TestValidator_StrictProfileAcceptsZeroValue(t *testing.T) {
+	v := New(Options{RequiredFields: []string{"weather.rainProbability"}})
+
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	msg["weather"].(map[string]interface{})["rainProbability"] = 0
+	data, _ := json.Marshal(msg)
+
+	if _, err := v.ValidateMessage(data); err != nil {
+		t.Errorf("Expected explicit 0 to satisfy a required field, got: %v", err)
+	}
+}
+
+func TestCheckFieldPaths(t *testing.T) {
+	if err := CheckFieldPaths(DefaultRequiredFields); err != nil {
+		t.Errorf("Expected default profile to be valid, got: %v", err)
+	}
+	if err := CheckFieldPaths([]string{"weather.rainProbablity"}); err == nil {
+		t.Error("Expected unknown field path to be rejected")
+	}
+}
+
+func TestValidateMessageAll_CollectsEveryError(t *testing.T) {
+	msg := map[string]interface{}{
+		"timestamp": "not-a-date",
+		"location":  map[string]interface{}{"city": "São Paulo", "latitude": 91.0},
+		"weather":   map[string]interface{}{"humidity": 150.0},
+		"source":    "open-meteo",
+	}
+	data, _ := json.Marshal(msg)
+
+	_, err := ValidateMessageAll(data)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %T (%v)", err, err)
+	}
+
+	expected := []string{"timestamp", "location.latitude", "weather.humidity", "weather.condition"}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, field := range expected {
+		if errs[i].Field != field {
+			t.Errorf("Expected error %d on %s, got %s", i, field, errs[i].Field)
+		}
+	}
+
+	if _, err := ValidateMessage(data); err.(ValidationError).Field != "timestamp" {
+		t.Errorf("Expected ValidateMessage to still stop at the first error, got %v", err)
+	}
+}
+
+func TestValidateMessageAll_MalformedJSON(t *testing.T) {
+	if _, err := ValidateMessageAll([]byte("{not json")); err == nil {
+		t.Error("Expected error for malformed JSON")
+	} else if _, ok := err.(ValidationErrors); ok {
+		t.Error("Expected a parse error, not ValidationErrors")
+	}
+}
+
+func TestReport(t *testing.T) {
+	v := New(Options{})
+
+	var buf bytes.Buffer
+	if !v.Report(&buf, createWindDirectionMessage(nil)) {
+		t.Errorf("Expected valid report, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"city": "São Paulo"`) {
+		t.Errorf("Expected pretty-printed message, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if v.Report(&buf, []byte(`{"location":{"latitude":-100}}`)) {
+		t.Error("Expected invalid report")
+	}
+	for _, line := range []string{
+		"invalid message: 5 validation error(s)",
+		"  timestamp [required]: required field is missing",
+		"  location.latitude [out_of_range]: must be between -90 and 90",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Expected report to contain %q, got:\n%s", line, buf.String())
+		}
+	}
+}
+
+func createTimestampMessage(timestamp interface{}) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	msg["timestamp"] = timestamp
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_TimestampFormats(t *testing.T) {
+	v := New(Options{TimestampFormats: []string{FormatRFC3339, FormatRFC3339Nano, FormatUnix, FormatUnixMillis}})
+
+	tests := []struct {
+		name      string
+		timestamp interface{}
+		expected  string
+	}{
+		{"rfc3339", "2025-12-03T14:30:00Z", "2025-12-03T14:30:00Z"},
+		{"rfc3339 nano with offset", "2025-12-03T14:30:00.123456-03:00", "2025-12-03T17:30:00.123456Z"},
+		{"unix seconds", 1764772200, "2025-12-03T14:30:00Z"},
+		{"unix seconds as string", "1764772200", "2025-12-03T14:30:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := v.ValidateMessage(createTimestampMessage(tt.timestamp))
+			if err != nil {
+				t.Fatalf("Expected %v to be accepted, got: %v", tt.timestamp, err)
+			}
+			if msg.Timestamp != tt.expected {
+				t.Errorf("Expected normalized %s, got %s", tt.expected, msg.Timestamp)
+			}
+		})
+	}
+}
+
+func TestValidator_TimestampUnixMillis(t *testing.T) {
+	v := New(Options{TimestampFormats: []string{FormatUnixMillis}})
+
+	msg, err := v.ValidateMessage(createTimestampMessage(1764772200123))
+	if err != nil {
+		t.Fatalf("Expected unix millis to be accepted, got: %v", err)
+	}
+	if msg.Timestamp != "2025-12-03T14:30:00.123Z" {
+		t.Errorf("Expected 2025-12-03T14:30:00.123Z, got %s", msg.Timestamp)
+	}
+}
+
+func TestValidator_TimestampNotInConfiguredFormats(t *testing.T) {
+	// RFC3339 only, the default: epoch values are rejected
+	_, err := ValidateMessage(createTimestampMessage(1764772200))
+	validationErr, ok := err.(ValidationError)
+	if !ok || validationErr.Field != "timestamp" || validationErr.Code != CodeInvalidFormat {
+		t.Errorf("Expected invalid_format timestamp error, got %v", err)
+	}
+
+	v := New(Options{TimestampFormats: []string{FormatUnix}})
+	if _, err := v.ValidateMessage(createTimestampMessage("2025-12-03T14:30:00Z")); err == nil {
+		t.Error("Expected RFC3339 timestamp to be rejected when only unix is configured")
+	}
+}
+
+func TestCheckTimestampFormats(t *testing.T) {
+	if err := CheckTimestampFormats([]string{FormatRFC3339, FormatUnixMillis}); err != nil {
+		t.Errorf("Expected known formats to be valid, got: %v", err)
+	}
+	if err := CheckTimestampFormats([]string{"iso8601"}); err == nil {
+		t.Error("Expected unknown format to be rejected")
+	}
+}
+
+func createCoordinatesMessage(latitude, longitude float64) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	location := msg["location"].(map[string]interface{})
+	location["latitude"] = latitude
+	location["longitude"] = longitude
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_RejectNullIsland(t *testing.T) {
+	strict := New(Options{RejectNullIsland: true})
+
+	_, err := strict.ValidateMessage(createCoordinatesMessage(0, 0))
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Expected ValidationError, got %T (%v)", err, err)
+	}
+	if validationErr.Field != "location" || validationErr.Code != CodeSuspicious || validationErr.Message != "coordinates are null island (0,0)" {
+		t.Errorf("Expected null island error, got %+v", validationErr)
+	}
+
+	if _, err := strict.ValidateMessage(createCoordinatesMessage(0, -46.6333)); err != nil {
+		t.Errorf("Expected a single zero coordinate to be accepted, got: %v", err)
+	}
+}
+
+func TestValidator_NullIslandAllowedByDefault(t *testing.T) {
+	if _, err := ValidateMessage(createCoordinatesMessage(0, 0)); err != nil {
+		t.Errorf("Expected 0,0 to be valid by default, got: %v", err)
+	}
+}
+
+func createRainMessage(condition string, rainProbability float64) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	weather := msg["weather"].(map[string]interface{})
+	weather["condition"] = condition
+	weather["rainProbability"] = rainProbability
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_CrossFieldRainConsistency(t *testing.T) {
+	strict := New(Options{CrossFieldChecks: true, MinRainProbability: 10})
+
+	for _, condition := range []string{"rainy", "Stormy", "snowy"} {
+		_, err := strict.ValidateMessage(createRainMessage(condition, 10))
+		validationErr, ok := err.(ValidationError)
+		if !ok {
+			t.Fatalf("Expected ValidationError for %q, got %T (%v)", condition, err, err)
+		}
+		if validationErr.Field != "weather" || validationErr.Code != CodeInconsistent || validationErr.Message != "rain probability inconsistent with condition" {
+			t.Errorf("Expected inconsistency error for %q, got %+v", condition, validationErr)
+		}
+	}
+
+	if _, err := strict.ValidateMessage(createRainMessage("rainy", 60)); err != nil {
+		t.Errorf("Expected rainy with 60%% rain probability to be valid, got: %v", err)
+	}
+	if _, err := strict.ValidateMessage(createRainMessage("sunny", 0)); err != nil {
+		t.Errorf("Expected sunny with 0%% rain probability to be valid, got: %v", err)
+	}
+}
+
+func TestValidator_CrossFieldChecksOffByDefault(t *testing.T) {
+	if _, err := ValidateMessage(createRainMessage("rainy", 0)); err != nil {
+		t.Errorf("Expected rainy with 0%% rain probability to be valid by default, got: %v", err)
+	}
+}
+
+func createVersionedMessage(version interface{}) []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	if version != nil {
+		msg["schemaVersion"] = version
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_SchemaVersionAccepted(t *testing.T) {
+	v := New(Options{MinSchemaVersion: 2})
+
+	for _, version := range []int{2, 3} {
+		msg, err := v.ValidateMessage(createVersionedMessage(version))
+		if err != nil {
+			t.Fatalf("Expected schema version %d to be valid, got: %v", version, err)
+		}
+		if msg.Version() != version {
+			t.Errorf("Expected version %d, got %d", version, msg.Version())
+		}
+	}
+}
+
+func TestValidator_SchemaVersionTooOld(t *testing.T) {
+	v := New(Options{MinSchemaVersion: 2})
+
+	_, err := v.ValidateMessage(createVersionedMessage(1))
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Expected ValidationError, got %T (%v)", err, err)
+	}
+	if validationErr.Field != "schemaVersion" || validationErr.Code != CodeUnsupported || validationErr.Message != "schema version 1 is below the minimum 2" {
+		t.Errorf("Expected schema version error, got %+v", validationErr)
+	}
+}
+
+func TestValidator_SchemaVersionAbsent(t *testing.T) {
+	msg, err := ValidateMessage(createVersionedMessage(nil))
+	if err != nil {
+		t.Fatalf("Expected a message without schemaVersion to be valid, got: %v", err)
+	}
+	if msg.Version() != DefaultSchemaVersion {
+		t.Errorf("Expected absent version to default to %d, got %d", DefaultSchemaVersion, msg.Version())
+	}
+
+	// The default still counts against the minimum
+	_, err = New(Options{MinSchemaVersion: 2}).ValidateMessage(createVersionedMessage(nil))
+	if validationErr, ok := err.(ValidationError); !ok || validationErr.Field != "schemaVersion" {
+		t.Errorf("Expected an unversioned message to be below minimum 2, got: %v", err)
+	}
+}
+
+// createMessageWithoutRain returns a valid message with rainProbability omitted
+func createMessageWithoutRain() []byte {
+	var msg map[string]interface{}
+	json.Unmarshal(createWindDirectionMessage(nil), &msg)
+	delete(msg["weather"].(map[string]interface{}), "rainProbability")
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func TestValidator_MissingRainProbabilityDefault(t *testing.T) {
+	msg, err := New(Options{MissingRainProbability: MissingDefault, DefaultRainProbability: 15}).ValidateMessage(createMessageWithoutRain())
+	if err != nil {
+		t.Fatalf("Expected message to be valid, got: %v", err)
+	}
+	if msg.Weather.RainProbability == nil || *msg.Weather.RainProbability != 15 {
+		t.Errorf("Expected rainProbability defaulted to 15, got %v", msg.Weather.RainProbability)
+	}
+
+	// The zero-value options keep sending 0, as before the policy existed
+	msg, _ = ValidateMessage(createMessageWithoutRain())
+	if msg.Weather.RainProbability == nil || *msg.Weather.RainProbability != 0 {
+		t.Errorf("Expected rainProbability defaulted to 0, got %v", msg.Weather.RainProbability)
+	}
+}
+
+func TestValidator_MissingRainProbabilityRequire(t *testing.T) {
+	_, err := New(Options{MissingRainProbability: MissingRequire}).ValidateMessage(createMessageWithoutRain())
+	validationErr, ok := err.(ValidationError)
+	if !ok || validationErr.Field != "weather.rainProbability" || validationErr.Code != CodeRequired {
+		t.Errorf("Expected required error for weather.rainProbability, got %v", err)
+	}
+
+	if _, err := New(Options{MissingRainProbability: MissingRequire}).ValidateMessage(createRainMessage("sunny", 0)); err != nil {
+		t.Errorf("Expected an explicit 0%% to satisfy the requirement, got: %v", err)
+	}
+}
+
+func TestValidator_MissingRainProbabilityOmit(t *testing.T) {
+	msg, err := New(Options{MissingRainProbability: MissingOmit}).ValidateMessage(createMessageWithoutRain())
+	if err != nil {
+		t.Fatalf("Expected message to be valid, got: %v", err)
+	}
+	if msg.Weather.RainProbability != nil {
+		t.Errorf("Expected rainProbability left absent, got %v", *msg.Weather.RainProbability)
+	}
+
+	data, _ := json.Marshal(msg)
+	if strings.Contains(string(data), "rainProbability") {
+		t.Errorf("Expected rainProbability omitted from the payload, got %s", data)
+	}
+}
+
+func TestCheckMissingPolicy(t *testing.T) {
+	for _, policy := range []string{"", MissingDefault, MissingRequire, MissingOmit} {
+		if err := CheckMissingPolicy(policy); err != nil {
+			t.Errorf("Expected %q to be accepted, got: %v", policy, err)
+		}
+	}
+	if err := CheckMissingPolicy("ignore"); err == nil {
+		t.Error("Expected unknown policy to be rejected")
+	}
+}
+
+func TestValidate_RejectsNonFiniteValues(t *testing.T) {
+	tests := []struct {
+		field string
+		set   func(msg *WeatherMessage, value float64)
+	}{
+		{"location.latitude", func(msg *WeatherMessage, value float64) { msg.Location.Latitude = value }},
+		{"location.longitude", func(msg *WeatherMessage, value float64) { msg.Location.Longitude = value }},
+		{"weather.temperature", func(msg *WeatherMessage, value float64) { msg.Weather.Temperature = value }},
+		{"weather.humidity", func(msg *WeatherMessage, value float64) { msg.Weather.Humidity = value }},
+		{"weather.windSpeed", func(msg *WeatherMessage, value float64) { msg.Weather.WindSpeed = value }},
+		{"weather.windDirection", func(msg *WeatherMessage, value float64) { msg.Weather.WindDirection = &value }},
+		{"weather.rainProbability", func(msg *WeatherMessage, value float64) { msg.Weather.RainProbability = &value }},
+	}
+
+	for _, tt := range tests {
+		for _, value := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			t.Run(fmt.Sprintf("%s=%v", tt.field, value), func(t *testing.T) {
+				msg := createEqualTestMessage()
+				tt.set(msg, value)
+
+				validationErr, ok := msg.Validate().(ValidationError)
+				if !ok || validationErr.Field != tt.field || validationErr.Code != CodeNotFinite {
+					t.Errorf("Expected not_finite error on %s, got %v", tt.field, msg.Validate())
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,51 @@
+//go:build testgen
+
+package validator_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/prop"
+	"queue-worker/internal/testgen"
+	"queue-worker/internal/validator"
+)
+
+// Property tests over messages generated by internal/testgen
+
+func TestPropertyGen_ValidMessagesAreAccepted(t *testing.T) {
+	properties := gopter.NewProperties(gopter.DefaultTestParameters())
+
+	properties.Property("generated valid messages are accepted", prop.ForAll(
+		func(msg *validator.WeatherMessage) bool {
+			_, err := validator.ValidateMessage(testgen.JSON(msg))
+			return err == nil
+		},
+		testgen.ValidMessage(),
+	))
+
+	properties.TestingRun(t)
+}
+
+// TestPropertyGen_OutOfRangeFieldIsRejected tests that a message with one
+// field out of range fails validation on that field
+func TestPropertyGen_OutOfRangeFieldIsRejected(t *testing.T) {
+	properties := gopter.NewProperties(gopter.DefaultTestParameters())
+
+	for _, field := range testgen.InvalidFields() {
+		properties.Property("out-of-range "+field+" is rejected", prop.ForAll(
+			func(invalid testgen.Invalid) bool {
+				_, err := validator.ValidateMessage(testgen.JSON(invalid.Message))
+
+				var validationErr validator.ValidationError
+				return errors.As(err, &validationErr) &&
+					validationErr.Field == invalid.Field &&
+					validationErr.Code == validator.CodeOutOfRange
+			},
+			testgen.InvalidMessageFor(field),
+		))
+	}
+
+	properties.TestingRun(t)
+}